@@ -15,28 +15,39 @@ USE espeniel;
 -- users 테이블 생성
 -- ========================================
 
+-- Go 서버의 migrations/mysql/0001_create_users.up.sql과 같은 스키마입니다.
+-- Go 서버는 이 테이블을 그대로 받아들이고 이후 마이그레이션만 적용하므로
+-- `go run . migrate up`으로 테이블을 만들어도 됩니다.
+-- deleted_at은 소프트 삭제된 unix 밀리초(0 = 활성)이므로 email/username은 deleted_at별로 고유합니다.
+-- created_at/updated_at 기본값은 시각을 직접 넣지 않는 Node.js 서버를 위한 것입니다.
+
 DROP TABLE IF EXISTS users;
 
 CREATE TABLE `users` (
-  `id` INT NOT NULL AUTO_INCREMENT,
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
   `email` VARCHAR(255) NOT NULL,
   `username` VARCHAR(50) NOT NULL,
   `password` VARCHAR(255) NOT NULL,
-  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `version` BIGINT UNSIGNED NOT NULL DEFAULT 1,
+  `created_at` DATETIME(3) NULL DEFAULT CURRENT_TIMESTAMP(3),
+  `updated_at` DATETIME(3) NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  `deleted_at` BIGINT UNSIGNED NOT NULL DEFAULT 0,
   PRIMARY KEY (`id`),
-  UNIQUE KEY `uk_email` (`email`),
-  UNIQUE KEY `uk_username` (`username`)
+  UNIQUE KEY `idx_users_email_deleted_at` (`email`, `deleted_at`),
+  UNIQUE KEY `idx_users_username_deleted_at` (`username`, `deleted_at`),
+  KEY `idx_users_deleted_at` (`deleted_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- ========================================
 -- 샘플 데이터 삽입 (선택사항)
 -- ========================================
 
+-- 비밀번호는 argon2id 해시로 저장됩니다 (평문: password123 / password456 / password789)
+-- 기존 평문 데이터는 Go 서버의 `go run . hash-passwords` 명령으로 변환할 수 있습니다
 INSERT INTO users (email, username, password) VALUES
-('user1@example.com', 'user1', 'password123'),
-('user2@example.com', 'user2', 'password456'),
-('user3@example.com', 'user3', 'password789');
+('user1@example.com', 'user1', '$argon2id$v=19$m=65536,t=3,p=2$I09OTbT4Kf/IrJW44avFOg$fyZKkztrpGm3QJwVCvDG3CkMsTfScX9cNoYTOW95joc'),
+('user2@example.com', 'user2', '$argon2id$v=19$m=65536,t=3,p=2$7CDek/n8PatMJHCBUsba2w$y0HYl7/sihUgAhby9kuH2zDAy4TIR65gzL7+TfX3afU'),
+('user3@example.com', 'user3', '$argon2id$v=19$m=65536,t=3,p=2$Xq+iDoNVN8VcxV74S/XGCw$s6FANn7Cd8BF5FehFfuMIAldmDm0N9a6dGDcll1kaCQ');
 
 -- ========================================
 -- 확인 쿼리
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Supported signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// minSecretLength is the minimum HS256 secret size in bytes
+const minSecretLength = 32
+
+// Config holds the access and refresh token settings
+type Config struct {
+	Algorithm  string
+	Secret     []byte        // HS256 shared secret
+	SignKey    crypto.Signer // RS256/EdDSA private key
+	KeyFile    string        // file SignKey was read from
+	Issuer     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// ConfigFromEnv builds a Config from the environment.
+//
+//	JWT_ALGORITHM         HS256 (default), RS256 or EdDSA
+//	JWT_SECRET            shared secret for HS256 (at least 32 bytes)
+//	JWT_PRIVATE_KEY_FILE  PEM private key for RS256/EdDSA
+//	JWT_ISSUER            token issuer (default "exercise")
+//	JWT_ACCESS_TTL        access token lifetime (default 15m)
+//	JWT_REFRESH_TTL       refresh token lifetime (default 720h)
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Algorithm:  os.Getenv("JWT_ALGORITHM"),
+		Issuer:     os.Getenv("JWT_ISSUER"),
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: 30 * 24 * time.Hour,
+	}
+
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgHS256
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = "exercise"
+	}
+	if v := os.Getenv("JWT_ACCESS_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			return cfg, fmt.Errorf("invalid JWT_ACCESS_TTL %q", v)
+		}
+		cfg.AccessTTL = ttl
+	}
+	if v := os.Getenv("JWT_REFRESH_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			return cfg, fmt.Errorf("invalid JWT_REFRESH_TTL %q", v)
+		}
+		cfg.RefreshTTL = ttl
+	}
+
+	switch cfg.Algorithm {
+	case AlgHS256:
+		cfg.Secret = []byte(os.Getenv("JWT_SECRET"))
+	case AlgRS256, AlgEdDSA:
+		path := os.Getenv("JWT_PRIVATE_KEY_FILE")
+		if path == "" {
+			return cfg, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", cfg.Algorithm)
+		}
+		key, err := LoadPrivateKey(path)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.SignKey, cfg.KeyFile = key, path
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// Validate checks that the key material matches the algorithm
+func (c Config) Validate() error {
+	switch c.Algorithm {
+	case AlgHS256:
+		if len(c.Secret) < minSecretLength {
+			return fmt.Errorf("JWT_SECRET must be at least %d bytes for HS256", minSecretLength)
+		}
+	case AlgRS256:
+		if _, ok := c.SignKey.(*rsa.PrivateKey); !ok {
+			return errors.New("RS256 requires an RSA private key")
+		}
+	case AlgEdDSA:
+		if _, ok := c.SignKey.(ed25519.PrivateKey); !ok {
+			return errors.New("EdDSA requires an Ed25519 private key")
+		}
+	default:
+		return fmt.Errorf("unsupported JWT algorithm %q (use HS256, RS256 or EdDSA)", c.Algorithm)
+	}
+
+	if c.AccessTTL <= 0 {
+		return errors.New("access token TTL must be positive")
+	}
+	if c.RefreshTTL <= c.AccessTTL {
+		return errors.New("refresh token TTL must be longer than the access token TTL")
+	}
+
+	return nil
+}
+
+// LoadPrivateKey reads a PEM encoded PKCS#8 or PKCS#1 private key
+func LoadPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %v", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %v", err)
+		}
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case ed25519.PrivateKey:
+			return k, nil
+		default:
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"common/models"
+	"common/password"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCredentials is returned when the login or password is wrong
+var ErrInvalidCredentials = errors.New("invalid email/username or password")
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// Authenticate looks up a user by email or username and verifies the
+// password. When the stored hash is outdated it is replaced with a hash
+// using the current parameters.
+func Authenticate(db *gorm.DB, login, plain string) (*models.User, error) {
+	login = strings.TrimSpace(login)
+	if login == "" || plain == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	var user models.User
+	err := db.Preload("Roles").Where("LOWER(email) = LOWER(?) OR LOWER(username) = LOWER(?)", login, login).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Spend the same time as a real check so unknown logins can't be detected by timing
+		password.Verify(plain, getDummyHash())
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %v", err)
+	}
+
+	ok, rehashed, err := password.Check(plain, user.Password)
+	if errors.Is(err, password.ErrUnsupportedHash) {
+		// A row left as plaintext (see hash-passwords) can't be verified; treat
+		// it as a failed login and keep the timing of a real check
+		slog.WarnContext(db.Statement.Context, "Stored password is not a supported hash", "user_id", user.ID, "error", err)
+		password.Verify(plain, getDummyHash())
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify password: %v", err)
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	if rehashed != "" {
+		// A failed upgrade must not block the login; it is retried next time
+		if err := db.Model(&user).UpdateColumn("password", rehashed).Error; err != nil {
+			slog.ErrorContext(db.Statement.Context, "Failed to rehash password", "user_id", user.ID, "error", err)
+		} else {
+			user.Password = rehashed
+		}
+	}
+
+	return &user, nil
+}
+
+func getDummyHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = password.Hash("dummy-password")
+	})
+	return dummyHash
+}
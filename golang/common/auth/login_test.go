@@ -0,0 +1,63 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"common/auth"
+	"common/internal/testdb"
+	"common/models"
+	"common/password"
+)
+
+func TestAuthenticateIgnoresCase(t *testing.T) {
+	db := testdb.Open(t)
+
+	hashed, err := password.Hash("Secret123!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Email: "Alice@Example.com", Username: "Alice", Password: hashed}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		login    string
+		password string
+		err      error
+	}{
+		{"Alice@Example.com", "Secret123!", nil},
+		{"alice@example.com", "Secret123!", nil},
+		{"ALICE", "Secret123!", nil},
+		{" alice ", "Secret123!", nil},
+		{"alice", "secret123!", auth.ErrInvalidCredentials},
+		{"bob", "Secret123!", auth.ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.login, func(t *testing.T) {
+			got, err := auth.Authenticate(db, tt.login, tt.password)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error %v, want %v", err, tt.err)
+			}
+			if err == nil && got.ID != user.ID {
+				t.Errorf("user %d, want %d", got.ID, user.ID)
+			}
+		})
+	}
+}
+
+func TestAuthenticatePlaintextRow(t *testing.T) {
+	db := testdb.Open(t)
+
+	user := &models.User{Email: "alice@example.com", Username: "alice", Password: "Secret123!"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plain := range []string{"Secret123!", "wrong"} {
+		if _, err := auth.Authenticate(db, "alice", plain); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Errorf("password %q: %v, want ErrInvalidCredentials", plain, err)
+		}
+	}
+}
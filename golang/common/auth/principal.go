@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"strings"
+)
+
+// Principal is the authenticated user of a request
+type Principal struct {
+	UserID   uint
+	Username string
+	Roles    []string
+}
+
+// HasRole reports whether the principal holds the named role
+func (p *Principal) HasRole(name string) bool {
+	if p == nil {
+		return false
+	}
+	for _, role := range p.Roles {
+		if role == name {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal stored in ctx, if any
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer <token>" header
+func BearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,288 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"common/breaker"
+	"common/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is
+	// presented again. The whole token family is revoked when this happens.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, all sessions of this login were revoked")
+
+	// errAlreadyRotated signals a lost rotation race inside a transaction
+	errAlreadyRotated = errors.New("refresh token already rotated")
+)
+
+// TokenPair is an access token together with the refresh token that renews it
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// SessionManager issues token pairs and manages refresh tokens stored in
+// the refresh_tokens table
+type SessionManager struct {
+	db      *gorm.DB
+	tokens  *TokenManager
+	breaker *breaker.Breaker
+}
+
+// NewSessionManager creates a SessionManager. Its database calls go through
+// b, the breaker shared with the user repository; nil calls the database
+// directly.
+func NewSessionManager(db *gorm.DB, tokens *TokenManager, b *breaker.Breaker) *SessionManager {
+	return &SessionManager{db: db, tokens: tokens, breaker: b}
+}
+
+// guard runs fn through the breaker, if any
+func (s *SessionManager) guard(fn func() error) error {
+	if s.breaker == nil {
+		return fn()
+	}
+	return s.breaker.Do(fn)
+}
+
+// Authenticate looks up a user by email or username and verifies the
+// password, see Authenticate
+func (s *SessionManager) Authenticate(ctx context.Context, login, plain string) (*models.User, error) {
+	var user *models.User
+	err := s.guard(func() (err error) {
+		user, err = Authenticate(s.db.WithContext(ctx), login, plain)
+		return err
+	})
+	return user, err
+}
+
+// Login starts a new session (refresh token family) for an authenticated user
+func (s *SessionManager) Login(ctx context.Context, user *models.User) (*TokenPair, error) {
+	familyID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	var refresh *refreshToken
+	var expiresAt time.Time
+	err = s.guard(func() (err error) {
+		refresh, expiresAt, err = s.createRefreshToken(s.db.WithContext(ctx), user.ID, familyID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.pair(user, refresh.plain, expiresAt)
+}
+
+// Refresh rotates a refresh token: the presented token is revoked and a new
+// one in the same family is issued together with a fresh access token.
+// Presenting a token that was already rotated revokes the whole family.
+func (s *SessionManager) Refresh(ctx context.Context, plain string) (*TokenPair, *models.User, error) {
+	var pair *TokenPair
+	var user *models.User
+	err := s.guard(func() (err error) {
+		pair, user, err = s.refresh(ctx, plain)
+		return err
+	})
+	return pair, user, err
+}
+
+// refresh implements Refresh
+func (s *SessionManager) refresh(ctx context.Context, plain string) (*TokenPair, *models.User, error) {
+	db := s.db.WithContext(ctx)
+	current, err := s.find(db, plain)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if current.RevokedAt != nil {
+		// A rotated token showing up again means it was stolen
+		if current.ReplacedBy != nil {
+			s.revokeCompromised(db, current.FamilyID)
+			return nil, nil, ErrRefreshTokenReused
+		}
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	var user models.User
+	if err := db.Preload("Roles").First(&user, current.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.revokeCompromised(db, current.FamilyID)
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	var next *refreshToken
+	var expiresAt time.Time
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Only one concurrent request may rotate a token
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAlreadyRotated
+		}
+
+		next, expiresAt, err = s.createRefreshToken(tx, current.UserID, current.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.RefreshToken{}).
+			Where("id = ?", current.ID).
+			Update("replaced_by", next.ID).Error
+	})
+	if errors.Is(err, errAlreadyRotated) {
+		s.revokeCompromised(db, current.FamilyID)
+		return nil, nil, ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+
+	pair, err := s.pair(&user, next.plain, expiresAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pair, &user, nil
+}
+
+// Logout revokes every refresh token in the family of the presented token
+func (s *SessionManager) Logout(ctx context.Context, plain string) error {
+	return s.guard(func() error {
+		db := s.db.WithContext(ctx)
+		current, err := s.find(db, plain)
+		if err != nil {
+			return err
+		}
+
+		return s.revokeFamily(db, current.FamilyID)
+	})
+}
+
+// RevokeUser revokes all active refresh tokens of a user and returns how
+// many were revoked. Access tokens already issued stay valid until they expire.
+func (s *SessionManager) RevokeUser(ctx context.Context, userID uint) (int64, error) {
+	var revoked int64
+	err := s.guard(func() error {
+		result := s.db.WithContext(ctx).Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", time.Now())
+		revoked = result.RowsAffected
+		return result.Error
+	})
+	return revoked, err
+}
+
+// refreshToken is a stored refresh token together with its plaintext value
+type refreshToken struct {
+	models.RefreshToken
+	plain string
+}
+
+func (s *SessionManager) createRefreshToken(db *gorm.DB, userID uint, familyID string) (*refreshToken, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to generate refresh token: %v", err)
+	}
+
+	token := &refreshToken{
+		RefreshToken: models.RefreshToken{
+			UserID:    userID,
+			FamilyID:  familyID,
+			ExpiresAt: time.Now().Add(s.tokens.RefreshTTL()),
+		},
+		plain: base64.RawURLEncoding.EncodeToString(buf),
+	}
+	token.TokenHash = hashToken(token.plain)
+
+	if err := db.Create(&token.RefreshToken).Error; err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to store refresh token: %v", err)
+	}
+
+	return token, token.ExpiresAt, nil
+}
+
+func (s *SessionManager) find(db *gorm.DB, plain string) (*models.RefreshToken, error) {
+	if plain == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var token models.RefreshToken
+	err := db.Where("token_hash = ?", hashToken(plain)).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (s *SessionManager) revokeFamily(db *gorm.DB, familyID string) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// revokeCompromised revokes a token family while rejecting a refresh request.
+// The request fails either way, so an error is only logged.
+func (s *SessionManager) revokeCompromised(db *gorm.DB, familyID string) {
+	if err := s.revokeFamily(db, familyID); err != nil {
+		slog.ErrorContext(db.Statement.Context, "Failed to revoke refresh token family", "family_id", familyID, "error", err)
+	}
+}
+
+func (s *SessionManager) pair(user *models.User, refresh string, refreshExpiresAt time.Time) (*TokenPair, error) {
+	access, accessExpiresAt, err := s.tokens.Issue(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+func hashToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random id: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
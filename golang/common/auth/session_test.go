@@ -0,0 +1,165 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"common/auth"
+	"common/breaker"
+	"common/database"
+	"common/internal/testdb"
+	"common/models"
+	"common/repository"
+)
+
+// newTokens returns a TokenManager signing with a test secret
+func newTokens(t *testing.T) *auth.TokenManager {
+	t.Helper()
+
+	tokens, err := auth.NewTokenManager(auth.Config{
+		Algorithm:  auth.AlgHS256,
+		Secret:     []byte("0123456789abcdef0123456789abcdef"),
+		Issuer:     "test",
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+// newSessions returns a SessionManager and a stored user to log in
+func newSessions(t *testing.T) (*auth.SessionManager, *models.User) {
+	t.Helper()
+
+	db := testdb.Open(t)
+	user := &models.User{Email: "alice@example.com", Username: "alice", Password: "hash"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return auth.NewSessionManager(db, newTokens(t), nil), user
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	sessions, user := newSessions(t)
+	ctx := context.Background()
+
+	first, err := sessions.Login(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, refreshed, err := sessions.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("refresh returned the presented token")
+	}
+	if refreshed.ID != user.ID {
+		t.Errorf("refresh returned user %d, want %d", refreshed.ID, user.ID)
+	}
+
+	third, _, err := sessions.Refresh(ctx, second.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh rotated token: %v", err)
+	}
+	if third.RefreshToken == second.RefreshToken {
+		t.Error("second refresh returned the presented token")
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	sessions, user := newSessions(t)
+	ctx := context.Background()
+
+	stolen, err := sessions.Login(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, _, err := sessions.Refresh(ctx, stolen.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := sessions.Login(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := sessions.Refresh(ctx, stolen.RefreshToken); !errors.Is(err, auth.ErrRefreshTokenReused) {
+		t.Fatalf("reusing a rotated token: %v, want ErrRefreshTokenReused", err)
+	}
+	if _, _, err := sessions.Refresh(ctx, rotated.RefreshToken); !errors.Is(err, auth.ErrInvalidRefreshToken) {
+		t.Errorf("token issued by the rotation: %v, want ErrInvalidRefreshToken", err)
+	}
+	// Other logins of the user are separate families and stay valid
+	if _, _, err := sessions.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Errorf("token of another login: %v", err)
+	}
+}
+
+func TestRefreshRejects(t *testing.T) {
+	sessions, user := newSessions(t)
+	ctx := context.Background()
+
+	loggedOut, err := sessions.Login(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions.Logout(ctx, loggedOut.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := sessions.Login(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.RevokeUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"unknown", "not-a-token"},
+		{"logged out", loggedOut.RefreshToken},
+		{"revoked", revoked.RefreshToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := sessions.Refresh(ctx, tt.token); !errors.Is(err, auth.ErrInvalidRefreshToken) {
+				t.Errorf("got %v, want ErrInvalidRefreshToken", err)
+			}
+		})
+	}
+}
+
+func TestSessionsShareBreaker(t *testing.T) {
+	db := testdb.Open(t)
+	b := repository.NewDatabaseBreaker(breaker.Config{Failures: 1, Cooldown: time.Minute})
+	sessions := auth.NewSessionManager(db, newTokens(t), b)
+	ctx := context.Background()
+
+	// Rejected tokens are the client's fault and leave the breaker closed
+	for i := 0; i < 3; i++ {
+		if _, _, err := sessions.Refresh(ctx, "not-a-token"); !errors.Is(err, auth.ErrInvalidRefreshToken) {
+			t.Fatalf("refresh %d: %v, want ErrInvalidRefreshToken", i, err)
+		}
+	}
+	if got := b.State(); got != breaker.Closed {
+		t.Fatalf("state after rejected tokens %v, want %v", got, breaker.Closed)
+	}
+
+	// A failing database opens it, and later calls fail fast
+	database.Close(db)
+	if _, err := sessions.Authenticate(ctx, "alice", "Secret123!"); err == nil {
+		t.Fatal("authenticate on a closed database succeeded")
+	}
+	var open *breaker.OpenError
+	if err := sessions.Logout(ctx, "not-a-token"); !errors.As(err, &open) {
+		t.Errorf("logout while open: %v, want an *OpenError", err)
+	}
+}
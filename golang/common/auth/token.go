@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"common/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned when an access token cannot be verified
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Claims are the claims carried by an access token
+type Claims struct {
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager issues and verifies signed access tokens
+type TokenManager struct {
+	cfg    Config
+	method jwt.SigningMethod
+	signer interface{}
+	verify interface{}
+}
+
+// NewTokenManager creates a TokenManager from a validated Config
+func NewTokenManager(cfg Config) (*TokenManager, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	m := &TokenManager{cfg: cfg}
+	switch cfg.Algorithm {
+	case AlgHS256:
+		m.method = jwt.SigningMethodHS256
+		m.signer, m.verify = cfg.Secret, cfg.Secret
+	case AlgRS256:
+		m.method = jwt.SigningMethodRS256
+		m.signer, m.verify = cfg.SignKey, cfg.SignKey.Public()
+	case AlgEdDSA:
+		m.method = jwt.SigningMethodEdDSA
+		m.signer, m.verify = cfg.SignKey, cfg.SignKey.Public()
+	}
+
+	return m, nil
+}
+
+// AccessTTL returns the lifetime of issued access tokens
+func (m *TokenManager) AccessTTL() time.Duration {
+	return m.cfg.AccessTTL
+}
+
+// RefreshTTL returns the lifetime of issued refresh tokens
+func (m *TokenManager) RefreshTTL() time.Duration {
+	return m.cfg.RefreshTTL
+}
+
+// Issue signs a new access token for user. The user's roles must be loaded.
+func (m *TokenManager) Issue(user *models.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.cfg.AccessTTL)
+
+	claims := Claims{
+		Username: user.Username,
+		Roles:    user.RoleNames(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.cfg.Issuer,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(m.method, claims).SignedString(m.signer)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %v", err)
+	}
+
+	return token, expiresAt, nil
+}
+
+// Parse verifies an access token and returns the authenticated principal
+func (m *TokenManager) Parse(token string) (*Principal, error) {
+	var claims Claims
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return m.verify, nil
+	},
+		jwt.WithValidMethods([]string{m.method.Alg()}),
+		jwt.WithIssuer(m.cfg.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	id, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &Principal{
+		UserID:   uint(id),
+		Username: claims.Username,
+		Roles:    claims.Roles,
+	}, nil
+}
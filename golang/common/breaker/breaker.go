@@ -0,0 +1,194 @@
+// Package breaker implements a circuit breaker. After a run of failed calls
+// to a dependency the breaker opens and rejects calls at once for a
+// cooldown, so requests fail fast instead of queueing for a dependency that
+// is down. It then lets one trial call through: a success closes the
+// breaker, a failure opens it again.
+package breaker
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// State is the state of a breaker
+type State int
+
+const (
+	// Closed lets every call through
+	Closed State = iota
+	// Open rejects every call until the cooldown has passed
+	Open
+	// HalfOpen lets one trial call through
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Config controls when a breaker opens
+type Config struct {
+	// Failures is how many consecutive failed calls open the breaker; 0
+	// disables it
+	Failures int
+	// Cooldown is how long the breaker stays open before a trial call
+	Cooldown time.Duration
+}
+
+// DefaultConfig opens after 5 consecutive failures for 10 seconds
+var DefaultConfig = Config{Failures: 5, Cooldown: 10 * time.Second}
+
+// ConfigFromEnv reads DB_BREAKER_FAILURES and DB_BREAKER_COOLDOWN, the
+// settings of the breaker around the database, falling back to
+// DefaultConfig
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig
+
+	if v := os.Getenv("DB_BREAKER_FAILURES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid DB_BREAKER_FAILURES %q", v)
+		}
+		cfg.Failures = n
+	}
+
+	if v := os.Getenv("DB_BREAKER_COOLDOWN"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid DB_BREAKER_COOLDOWN %q", v)
+		}
+		cfg.Cooldown = d
+	}
+
+	return cfg, nil
+}
+
+// OpenError is returned instead of calling the dependency while the breaker
+// is open
+type OpenError struct {
+	Name string
+	// RetryAfter is when the breaker lets a call through again
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s unavailable: circuit breaker is open", e.Name)
+}
+
+// trialWait is the RetryAfter of calls rejected while the trial call runs
+const trialWait = time.Second
+
+// Breaker guards calls to one dependency. It is safe for concurrent use.
+type Breaker struct {
+	name    string
+	cfg     Config
+	failure func(error) bool
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	until    time.Time // end of the cooldown while open
+}
+
+// New returns a closed Breaker. failure reports whether an error returned
+// by a call means the dependency is failing, as opposed to e.g. a record
+// that does not exist; nil counts every error.
+func New(name string, cfg Config, failure func(error) bool) *Breaker {
+	if failure == nil {
+		failure = func(err error) bool { return err != nil }
+	}
+	return &Breaker{name: name, cfg: cfg, failure: failure}
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Do calls fn unless the breaker is open, in which case it returns an
+// *OpenError without calling it
+func (b *Breaker) Do(fn func() error) error {
+	if b.cfg.Failures == 0 {
+		return fn()
+	}
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	// A panicking call counts as failed, so a trial call cannot leave the
+	// breaker half-open for good
+	failed := true
+	defer func() { b.record(failed) }()
+
+	err := fn()
+	failed = err != nil && b.failure(err)
+	return err
+}
+
+// allow reports whether a call may proceed, moving an open breaker whose
+// cooldown has passed to half-open
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		wait := time.Until(b.until)
+		if wait > 0 {
+			return &OpenError{Name: b.name, RetryAfter: wait}
+		}
+		b.transition(HalfOpen)
+		return nil
+	case HalfOpen:
+		return &OpenError{Name: b.name, RetryAfter: trialWait}
+	default:
+		return nil
+	}
+}
+
+// record counts the result of an allowed call
+func (b *Breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		if b.state != Closed {
+			b.transition(Closed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.cfg.Failures {
+		b.until = time.Now().Add(b.cfg.Cooldown)
+		if b.state != Open {
+			b.transition(Open)
+		}
+	}
+}
+
+// transition changes the state and logs it; b.mu must be held
+func (b *Breaker) transition(to State) {
+	b.state = to
+	switch to {
+	case Open:
+		slog.Warn("circuit breaker opened", "breaker", b.name,
+			"failures", b.failures, "cooldown", b.cfg.Cooldown.String())
+	default:
+		slog.Info("circuit breaker "+to.String(), "breaker", b.name)
+	}
+}
@@ -0,0 +1,137 @@
+package breaker_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"common/breaker"
+)
+
+const cooldown = 20 * time.Millisecond
+
+var errDown = errors.New("connection refused")
+
+func TestBreaker(t *testing.T) {
+	b := breaker.New("test", breaker.Config{Failures: 2, Cooldown: cooldown}, nil)
+
+	// Each step runs after the previous one on the same breaker
+	steps := []struct {
+		name   string
+		wait   time.Duration
+		err    error
+		called bool
+		open   bool
+		state  breaker.State
+	}{
+		{name: "first failure", err: errDown, called: true, state: breaker.Closed},
+		{name: "success resets the count", called: true, state: breaker.Closed},
+		{name: "failure after success", err: errDown, called: true, state: breaker.Closed},
+		{name: "second failure opens", err: errDown, called: true, state: breaker.Open},
+		{name: "open rejects", called: false, open: true, state: breaker.Open},
+		{name: "failed trial reopens", wait: cooldown, err: errDown, called: true, state: breaker.Open},
+		{name: "reopened rejects", called: false, open: true, state: breaker.Open},
+		{name: "successful trial closes", wait: cooldown, called: true, state: breaker.Closed},
+		{name: "closed lets calls through", called: true, state: breaker.Closed},
+	}
+	for _, st := range steps {
+		time.Sleep(st.wait)
+
+		called := false
+		err := b.Do(func() error {
+			called = true
+			return st.err
+		})
+
+		if called != st.called {
+			t.Errorf("%s: called %v, want %v", st.name, called, st.called)
+		}
+		var open *breaker.OpenError
+		if got := errors.As(err, &open); got != st.open {
+			t.Errorf("%s: open error %v, want %v", st.name, err, st.open)
+		}
+		if open != nil && (open.RetryAfter <= 0 || open.RetryAfter > cooldown) {
+			t.Errorf("%s: RetryAfter %v, want within the cooldown %v", st.name, open.RetryAfter, cooldown)
+		}
+		if !st.open && err != st.err {
+			t.Errorf("%s: error %v, want %v", st.name, err, st.err)
+		}
+		if got := b.State(); got != st.state {
+			t.Errorf("%s: state %v, want %v", st.name, got, st.state)
+		}
+	}
+}
+
+func TestBreakerHalfOpenAllowsOneTrial(t *testing.T) {
+	b := breaker.New("test", breaker.Config{Failures: 1, Cooldown: cooldown}, nil)
+	b.Do(func() error { return errDown })
+	time.Sleep(cooldown)
+
+	var during error
+	err := b.Do(func() error {
+		if got := b.State(); got != breaker.HalfOpen {
+			t.Errorf("state during trial %v, want %v", got, breaker.HalfOpen)
+		}
+		during = b.Do(func() error {
+			t.Error("second call ran during the trial")
+			return nil
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var open *breaker.OpenError
+	if !errors.As(during, &open) {
+		t.Fatalf("call during trial: %v, want an *OpenError", during)
+	}
+	if open.RetryAfter <= 0 {
+		t.Errorf("RetryAfter %v during trial, want positive", open.RetryAfter)
+	}
+	if got := b.State(); got != breaker.Closed {
+		t.Errorf("state %v, want %v", got, breaker.Closed)
+	}
+}
+
+func TestBreakerIgnoresErrors(t *testing.T) {
+	errMissing := errors.New("not found")
+	b := breaker.New("test", breaker.Config{Failures: 1, Cooldown: cooldown}, func(err error) bool {
+		return !errors.Is(err, errMissing)
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := b.Do(func() error { return errMissing }); err != errMissing {
+			t.Fatalf("call %d: %v, want %v", i, err, errMissing)
+		}
+	}
+	if got := b.State(); got != breaker.Closed {
+		t.Errorf("state %v, want %v", got, breaker.Closed)
+	}
+}
+
+func TestBreakerPanicCountsAsFailure(t *testing.T) {
+	b := breaker.New("test", breaker.Config{Failures: 1, Cooldown: cooldown}, nil)
+
+	func() {
+		defer func() { recover() }()
+		b.Do(func() error { panic("boom") })
+	}()
+
+	if got := b.State(); got != breaker.Open {
+		t.Errorf("state %v, want %v", got, breaker.Open)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := breaker.New("test", breaker.Config{Failures: 0, Cooldown: cooldown}, nil)
+
+	for i := 0; i < 10; i++ {
+		if err := b.Do(func() error { return errDown }); err != errDown {
+			t.Fatalf("call %d: %v, want %v", i, err, errDown)
+		}
+	}
+	if got := b.State(); got != breaker.Closed {
+		t.Errorf("state %v, want %v", got, breaker.Closed)
+	}
+}
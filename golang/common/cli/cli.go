@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"common/migrations"
+	"common/models"
+	"common/password"
+	"common/rbac"
+	"common/settings"
+	"common/trash"
+
+	"gorm.io/gorm"
+)
+
+// Run executes the maintenance command named by args[0] against db.
+// It returns handled=false when args is empty so the caller can start
+// the HTTP server instead.
+func Run(args []string, db *gorm.DB) (handled bool, err error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "hash-passwords":
+		return true, hashPasswords(db)
+	case "grant-role":
+		return true, grantRole(db, args[1:])
+	case "purge-trash":
+		return true, purgeTrash(db)
+	case "migrate":
+		return true, migrate(db, args[1:])
+	default:
+		return true, fmt.Errorf("unknown command %q (available: hash-passwords, grant-role, purge-trash, migrate, config)", args[0])
+	}
+}
+
+// RunConfig executes the config command, which runs before the database is
+// opened. "config print" writes the configuration with secrets masked.
+func RunConfig(args []string, cfg settings.Config) (handled bool, err error) {
+	if len(args) == 0 || args[0] != "config" {
+		return false, nil
+	}
+	if len(args) != 2 || args[1] != "print" {
+		return true, fmt.Errorf("usage: config print")
+	}
+	return true, settings.Print(os.Stdout, cfg)
+}
+
+// ManagesSchema reports whether args name the migrate command, which must
+// run before the schema is migrated on startup
+func ManagesSchema(args []string) bool {
+	return len(args) > 0 && args[0] == "migrate"
+}
+
+// hashPasswords converts plaintext passwords left over from before hashing
+// was introduced
+func hashPasswords(db *gorm.DB) error {
+	n, err := password.MigratePlaintext(db, password.Default())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Hashed %d plaintext password(s)", n)
+	return nil
+}
+
+// grantRole adds a role to a user, e.g. to bootstrap the first admin:
+//
+//	grant-role <email|username> <role>
+func grantRole(db *gorm.DB, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: grant-role <email|username> <role>")
+	}
+	login, roleName := args[0], args[1]
+
+	var user models.User
+	if err := db.Preload("Roles").Where("LOWER(email) = LOWER(?) OR LOWER(username) = LOWER(?)", login, login).First(&user).Error; err != nil {
+		return fmt.Errorf("user %q not found: %v", login, err)
+	}
+
+	if err := rbac.SetUserRoles(db, &user, append(user.RoleNames(), roleName)); err != nil {
+		return err
+	}
+
+	log.Printf("User %q now has roles %v", user.Username, user.RoleNames())
+	return nil
+}
+
+// purgeTrash permanently deletes users that were soft-deleted longer ago
+// than TRASH_RETENTION
+func purgeTrash(db *gorm.DB) error {
+	cfg, err := trash.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	if cfg.Retention == 0 {
+		return fmt.Errorf("TRASH_RETENTION is 0, purging is disabled")
+	}
+
+	n, err := trash.Purge(db, cfg.Retention)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Purged %d deleted user(s)", n)
+	return nil
+}
+
+// migrate applies, rolls back, lists or creates schema migrations:
+//
+//	migrate up
+//	migrate down [steps]
+//	migrate status
+//	migrate create <name>
+func migrate(db *gorm.DB, args []string) error {
+	const usage = "usage: migrate up | down [steps] | status | create <name>"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: migrate create <name>")
+		}
+		return createMigration(args[1])
+	}
+
+	m, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up()
+		for _, migration := range applied {
+			log.Printf("Applied migration %s", migration)
+		}
+		if err == nil && len(applied) == 0 {
+			log.Println("Schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		rolledBack, err := m.Down(steps)
+		for _, migration := range rolledBack {
+			log.Printf("Rolled back migration %s", migration)
+		}
+		return err
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-40s %s\n", status.Migration, applied)
+		}
+		return nil
+	default:
+		return errors.New(usage)
+	}
+}
+
+// createMigration writes empty up/down files for a new migration into
+// MIGRATIONS_DIR (default ../common/migrations, relative to a server directory)
+func createMigration(name string) error {
+	dir := os.Getenv("MIGRATIONS_DIR")
+	if dir == "" {
+		dir = "../common/migrations"
+	}
+
+	created, err := migrations.Create(dir, name)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Created %s", strings.Join(created, ", "))
+	return nil
+}
@@ -0,0 +1,221 @@
+package database
+
+import (
+	"common/logging"
+	"common/tracing"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	gomysql "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Supported database drivers
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Config holds the database connection settings
+type Config struct {
+	Driver   string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string // database name, or the file path for SQLite
+	SSLMode  string // PostgreSQL only
+
+	// Connection pool
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// ConnectTimeout is how long Open retries an unreachable database; 0
+	// tries once
+	ConnectTimeout time.Duration
+}
+
+// DefaultConnectTimeout is how long Open waits for the database when
+// DB_CONNECT_TIMEOUT is not set
+const DefaultConnectTimeout = 30 * time.Second
+
+// ConfigFromEnv builds a Config from the environment.
+//
+//	DB_DRIVER    mysql (default, also MariaDB), postgres or sqlite
+//	DB_HOST      server host
+//	DB_PORT      server port (default 3306 for mysql, 5432 for postgres)
+//	DB_USER      user name
+//	DB_PASSWORD  password
+//	DB_NAME      database name, or the database file for sqlite (default exercise.db)
+//	DB_SSLMODE   PostgreSQL sslmode (default disable)
+//
+//	DB_MAX_OPEN_CONNS     connection pool size (default 5, 1 for sqlite)
+//	DB_MAX_IDLE_CONNS     connections kept open while idle (default 0, 1 for sqlite)
+//	DB_CONN_MAX_LIFETIME  how long a connection is reused (default 1h)
+//	DB_CONNECT_TIMEOUT    how long to retry connecting at startup (default 30s, 0 tries once)
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Driver:   os.Getenv("DB_DRIVER"),
+		Host:     os.Getenv("DB_HOST"),
+		Port:     os.Getenv("DB_PORT"),
+		User:     os.Getenv("DB_USER"),
+		Password: os.Getenv("DB_PASSWORD"),
+		Name:     os.Getenv("DB_NAME"),
+		SSLMode:  os.Getenv("DB_SSLMODE"),
+	}
+
+	if cfg.Driver == "" {
+		cfg.Driver = DriverMySQL
+	}
+
+	// SQLite allows a single writer, so it gets a single connection, which
+	// is kept rather than reopening the file per query
+	cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.ConnMaxLifetime = 5, 0, time.Hour
+	cfg.ConnectTimeout = DefaultConnectTimeout
+	switch cfg.Driver {
+	case DriverMySQL:
+		if cfg.Port == "" {
+			cfg.Port = "3306"
+		}
+	case DriverPostgres:
+		if cfg.Port == "" {
+			cfg.Port = "5432"
+		}
+		if cfg.SSLMode == "" {
+			cfg.SSLMode = "disable"
+		}
+	case DriverSQLite:
+		if cfg.Name == "" {
+			cfg.Name = "exercise.db"
+		}
+		cfg.MaxOpenConns, cfg.MaxIdleConns = 1, 1
+	default:
+		return cfg, fmt.Errorf("unsupported DB_DRIVER %q (use mysql, postgres or sqlite)", cfg.Driver)
+	}
+
+	if cfg.Driver != DriverSQLite {
+		if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+			return cfg, fmt.Errorf("invalid DB_PORT %q", cfg.Port)
+		}
+	}
+	if v := os.Getenv("DB_MAX_OPEN_CONNS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return cfg, fmt.Errorf("invalid DB_MAX_OPEN_CONNS %q", v)
+		}
+		if cfg.Driver == DriverSQLite && n != 1 {
+			return cfg, fmt.Errorf("DB_MAX_OPEN_CONNS must be 1 for sqlite")
+		}
+		cfg.MaxOpenConns = n
+	}
+	if v := os.Getenv("DB_MAX_IDLE_CONNS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid DB_MAX_IDLE_CONNS %q", v)
+		}
+		cfg.MaxIdleConns = n
+	}
+	if cfg.MaxIdleConns > cfg.MaxOpenConns {
+		return cfg, fmt.Errorf("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", cfg.MaxIdleConns, cfg.MaxOpenConns)
+	}
+	if v := os.Getenv("DB_CONN_MAX_LIFETIME"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return cfg, fmt.Errorf("invalid DB_CONN_MAX_LIFETIME %q", v)
+		}
+		cfg.ConnMaxLifetime = d
+	}
+	if v := os.Getenv("DB_CONNECT_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return cfg, fmt.Errorf("invalid DB_CONNECT_TIMEOUT %q", v)
+		}
+		cfg.ConnectTimeout = d
+	}
+
+	return cfg, nil
+}
+
+// Dialector returns the GORM dialector with a DSN built for the driver
+func (c Config) Dialector() gorm.Dialector {
+	switch c.Driver {
+	case DriverPostgres:
+		dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d TimeZone=UTC",
+			quote(c.Host), quote(c.Port), quote(c.User), quote(c.Password), quote(c.Name), quote(c.SSLMode), int(dialTimeout.Seconds()))
+		return postgres.Open(dsn)
+	case DriverSQLite:
+		// Wait for a locked database instead of failing, and enforce the
+		// foreign keys of user_roles like the other databases do
+		pragmas := url.Values{"_pragma": {"busy_timeout(5000)", "journal_mode(WAL)", "foreign_keys(1)"}}
+		return sqlite.Open("file:" + c.Name + "?" + pragmas.Encode())
+	default:
+		// FormatDSN escapes the credentials and stores times in UTC
+		dsn := gomysql.NewConfig()
+		dsn.User = c.User
+		dsn.Passwd = c.Password
+		dsn.Net = "tcp"
+		dsn.Addr = net.JoinHostPort(c.Host, c.Port)
+		dsn.DBName = c.Name
+		dsn.Params = map[string]string{"charset": "utf8mb4"}
+		dsn.ParseTime = true
+		dsn.Loc = time.UTC
+		dsn.Timeout = dialTimeout
+		return mysql.Open(dsn.FormatDSN())
+	}
+}
+
+// Open connects to the database and configures the connection pool. An
+// unreachable database is retried until cfg.ConnectTimeout has passed.
+func Open(cfg Config) (*gorm.DB, error) {
+	db, err := connect(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+	// Queries are logged as JSON without their bound values
+	db.Logger = logging.NewGormLogger()
+
+	// Connection pool settings
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %v", err)
+	}
+
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	// Trace every query as a child of the request span
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register query tracing: %v", err)
+	}
+
+	return db, nil
+}
+
+// escaper escapes quotes and backslashes in a connection string value
+var escaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// quote quotes a PostgreSQL connection string value
+func quote(v string) string {
+	return "'" + escaper.Replace(v) + "'"
+}
+
+// Close closes the connection pool of db. Queries still running finish
+// first; new queries fail.
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %v", err)
+	}
+	return sqlDB.Close()
+}
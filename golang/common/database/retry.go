@@ -0,0 +1,71 @@
+package database
+
+import (
+	"common/logging"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dialTimeout bounds opening one connection, so an unreachable host fails
+// the attempt instead of hanging until the operating system gives up
+const dialTimeout = 5 * time.Second
+
+// Backoff between connection attempts: it doubles from initialBackoff up to
+// maxBackoff
+const (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+)
+
+// connect opens the database, retrying with exponential backoff and jitter
+// until cfg.ConnectTimeout has passed. A server started together with its
+// database, e.g. by docker compose, then waits for it instead of exiting.
+func connect(cfg Config) (*gorm.DB, error) {
+	deadline := time.Now().Add(cfg.ConnectTimeout)
+	backoff := initialBackoff
+
+	for attempt := 1; ; attempt++ {
+		db, err := gorm.Open(cfg.Dialector(), &gorm.Config{
+			// Failed attempts are logged below, once each
+			Logger: logging.NewGormLogger().LogMode(logger.Silent),
+			NowFunc: func() time.Time {
+				return time.Now().UTC()
+			},
+			// Report unique violations as gorm.ErrDuplicatedKey on every driver
+			TranslateError: true,
+		})
+		if err == nil {
+			return db, nil
+		}
+		// A failed ping leaves the pool open
+		if db != nil {
+			if sqlDB, _ := db.DB(); sqlDB != nil {
+				sqlDB.Close()
+			}
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, err
+		}
+
+		// Half the backoff plus a random half, so servers restarted
+		// together do not retry in lockstep
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
+		if delay > remaining {
+			delay = remaining
+		}
+		slog.Warn("database not reachable, retrying",
+			"attempt", attempt, "retry_in", delay.String(), "error", err)
+		time.Sleep(delay)
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
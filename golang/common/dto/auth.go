@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"common/auth"
+	"common/models"
+	"common/validation"
+	"time"
+)
+
+// LoginRequest is the request body of POST /api/auth/login.
+// Either email or username identifies the user.
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Username string `json:"username"`
+	Password string `json:"password" validate:"required"`
+}
+
+// Login returns the identifier the user logs in with
+func (r *LoginRequest) Login() string {
+	if r.Email != "" {
+		return r.Email
+	}
+	return r.Username
+}
+
+// Validate checks that the password is present. The password policy is not
+// applied: it only constrains new passwords.
+func (r *LoginRequest) Validate() error {
+	return validation.Struct(r)
+}
+
+// RefreshRequest is the request body of POST /api/auth/refresh and POST /api/auth/logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// Validate checks that the refresh token is present
+func (r *RefreshRequest) Validate() error {
+	return validation.Struct(r)
+}
+
+// TokenResponse is returned after a successful login or token refresh
+type TokenResponse struct {
+	AccessToken      string       `json:"access_token"`
+	TokenType        string       `json:"token_type"`
+	ExpiresIn        int64        `json:"expires_in"`
+	RefreshToken     string       `json:"refresh_token"`
+	RefreshExpiresIn int64        `json:"refresh_expires_in"`
+	User             UserResponse `json:"user"`
+}
+
+// NewTokenResponse builds the response for an issued token pair
+func NewTokenResponse(pair *auth.TokenPair, user *models.User) TokenResponse {
+	return TokenResponse{
+		AccessToken:      pair.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        secondsUntil(pair.AccessExpiresAt),
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresIn: secondsUntil(pair.RefreshExpiresAt),
+		User:             NewUserResponse(user),
+	}
+}
+
+func secondsUntil(t time.Time) int64 {
+	return int64(time.Until(t).Round(time.Second).Seconds())
+}
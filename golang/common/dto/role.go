@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"common/models"
+	"common/rbac"
+	"common/validation"
+)
+
+// UserRolesRequest is the request body of PUT /api/users/:id/roles
+type UserRolesRequest struct {
+	Roles []string `json:"roles" validate:"required"`
+}
+
+// Validate checks that the role list is present. An empty list is valid
+// and removes every role.
+func (r *UserRolesRequest) Validate() error {
+	return validation.Struct(r)
+}
+
+// RoleResponse is the public representation of a role
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// NewRoleResponses lists every role of the access policy
+func NewRoleResponses() []RoleResponse {
+	responses := make([]RoleResponse, 0, len(rbac.Policy))
+	for _, def := range rbac.Policy {
+		responses = append(responses, RoleResponse{
+			Name:        def.Name,
+			Description: def.Description,
+			Permissions: def.Permissions(),
+		})
+	}
+	return responses
+}
+
+// UserRolesResponse lists the roles held by a user
+type UserRolesResponse struct {
+	UserID uint     `json:"user_id"`
+	Roles  []string `json:"roles"`
+}
+
+// NewUserRolesResponse maps a user with preloaded roles to its role list
+func NewUserRolesResponse(user *models.User) UserRolesResponse {
+	return UserRolesResponse{
+		UserID: user.ID,
+		Roles:  user.RoleNames(),
+	}
+}
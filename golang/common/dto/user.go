@@ -0,0 +1,191 @@
+package dto
+
+import (
+	"bytes"
+	"common/models"
+	"common/password"
+	"common/patch"
+	"common/problem"
+	"common/validation"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// CreateUserRequest is the request body of POST /api/users.
+// The lengths match the columns of the users table.
+type CreateUserRequest struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Username string `json:"username" validate:"required,min=3,max=50,username"`
+	Password string `json:"password" validate:"required,min=8,max=128,password"`
+}
+
+// UpdateUserRequest is the request body of PUT /api/users/:id.
+// PUT replaces the user, so every field is required.
+type UpdateUserRequest struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Username string `json:"username" validate:"required,min=3,max=50,username"`
+	Password string `json:"password" validate:"required,min=8,max=128,password"`
+}
+
+// UserResponse is the public representation of a user.
+// The password is write-only and never part of a response.
+type UserResponse struct {
+	ID        uint       `json:"id"`
+	Email     string     `json:"email"`
+	Username  string     `json:"username"`
+	Roles     []string   `json:"roles"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// UserChanges are the fields written by an update. Nil fields are left untouched.
+type UserChanges struct {
+	Email    *string
+	Username *string
+	Password *string
+}
+
+// userDocument is the document a PATCH request is applied to
+type userDocument struct {
+	Email    *string `json:"email"`
+	Username *string `json:"username"`
+	Password *string `json:"password,omitempty"`
+}
+
+// NewUserResponse maps a User model to its public representation.
+// The user's roles must be preloaded.
+func NewUserResponse(user *models.User) UserResponse {
+	return UserResponse{
+		ID:        user.ID,
+		Email:     user.Email,
+		Username:  user.Username,
+		Roles:     user.RoleNames(),
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		DeletedAt: user.DeletedTime(),
+	}
+}
+
+// NewUserResponses maps a list of User models to their public representation
+func NewUserResponses(users []models.User) []UserResponse {
+	responses := make([]UserResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, NewUserResponse(&users[i]))
+	}
+	return responses
+}
+
+// Validate checks that every field is present and well-formed
+func (r *CreateUserRequest) Validate() error {
+	return validation.Struct(r)
+}
+
+// ToModel builds a new User from the request.
+// The password is copied as-is and must be hashed before saving.
+func (r *CreateUserRequest) ToModel() models.User {
+	return models.User{
+		Email:    r.Email,
+		Username: r.Username,
+		Password: r.Password,
+	}
+}
+
+// Validate checks that every field is present and well-formed
+func (r *UpdateUserRequest) Validate() error {
+	return validation.Struct(r)
+}
+
+// Changes returns the request as a full replacement of the user's fields
+func (r *UpdateUserRequest) Changes() UserChanges {
+	return UserChanges{
+		Email:    &r.Email,
+		Username: &r.Username,
+		Password: &r.Password,
+	}
+}
+
+// PatchUser applies a JSON Merge Patch or JSON Patch body to user and returns
+// the fields it changed. The patch sees the user as
+//
+//	{"email": "...", "username": "..."}
+//
+// and may add a "password" member to set a new password.
+func PatchUser(user *models.User, contentType string, body []byte) (UserChanges, error) {
+	doc, err := json.Marshal(userDocument{Email: &user.Email, Username: &user.Username})
+	if err != nil {
+		return UserChanges{}, err
+	}
+
+	patched, err := patch.Apply(contentType, doc, body)
+	if err != nil {
+		return UserChanges{}, err
+	}
+
+	var result userDocument
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&result); err != nil {
+		return UserChanges{}, fmt.Errorf("%w: %v", patch.ErrCannotApply, err)
+	}
+
+	if result.Email == nil {
+		return UserChanges{}, validation.Fail("email", problem.FieldRequired, "email is required")
+	}
+	if result.Username == nil {
+		return UserChanges{}, validation.Fail("username", problem.FieldRequired, "username is required")
+	}
+
+	// Only changed fields are validated, so a user stored before a rule was
+	// tightened can still change its other fields
+	var changes UserChanges
+	var changed []string
+	if *result.Email != user.Email {
+		changes.Email = result.Email
+		changed = append(changed, "email")
+	}
+	if *result.Username != user.Username {
+		changes.Username = result.Username
+		changed = append(changed, "username")
+	}
+	if result.Password != nil {
+		changes.Password = result.Password
+		changed = append(changed, "password")
+	}
+
+	req := UpdateUserRequest{Email: *result.Email, Username: *result.Username}
+	if result.Password != nil {
+		req.Password = *result.Password
+	}
+	if err := validation.Fields(&req, changed...); err != nil {
+		return UserChanges{}, err
+	}
+
+	return changes, nil
+}
+
+// IsEmpty reports whether the changes leave the user untouched
+func (c UserChanges) IsEmpty() bool {
+	return c.Email == nil && c.Username == nil && c.Password == nil
+}
+
+// Hash returns the changes with a new password replaced by its hash.
+// passwordChanged reports whether the password differs from the stored one.
+func (c UserChanges) Hash(user *models.User) (hashed UserChanges, passwordChanged bool, err error) {
+	hashed = c
+	if c.Password == nil {
+		return hashed, false, nil
+	}
+
+	unchanged, _ := password.Verify(*c.Password, user.Password)
+
+	// Store only the password hash
+	hash, err := password.Hash(*c.Password)
+	if err != nil {
+		return UserChanges{}, false, fmt.Errorf("failed to hash password: %v", err)
+	}
+	hashed.Password = &hash
+
+	return hashed, !unchanged, nil
+}
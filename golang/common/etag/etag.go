@@ -0,0 +1,102 @@
+package etag
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	// ErrPreconditionRequired is returned when If-Match is required but missing
+	ErrPreconditionRequired = errors.New("If-Match header is required")
+	// ErrPreconditionFailed is returned when the resource changed since it was read
+	ErrPreconditionFailed = errors.New("resource has been modified; fetch it again and retry")
+)
+
+var requireIfMatch atomic.Bool
+
+// RequireIfMatchFromEnv reads REQUIRE_IF_MATCH; true rejects writes without
+// an If-Match header. It is false when the variable is not set.
+func RequireIfMatchFromEnv() (bool, error) {
+	v := os.Getenv("REQUIRE_IF_MATCH")
+	if v == "" {
+		return false, nil
+	}
+
+	require, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid REQUIRE_IF_MATCH %q: %v", v, err)
+	}
+	return require, nil
+}
+
+// SetRequireIfMatch sets whether writes without an If-Match header are rejected
+func SetRequireIfMatch(require bool) {
+	requireIfMatch.Store(require)
+}
+
+// For returns the strong entity tag of a versioned resource
+func For(id, version uint) string {
+	return fmt.Sprintf(`"%d-%d"`, id, version)
+}
+
+// CheckIfMatch validates the If-Match header of a write against the current
+// entity tag. A missing header is accepted unless If-Match is required.
+func CheckIfMatch(header, current string) error {
+	if strings.TrimSpace(header) == "" {
+		if requireIfMatch.Load() {
+			return ErrPreconditionRequired
+		}
+		return nil
+	}
+
+	// If-Match uses the strong comparison, so weak tags never match
+	if !matches(header, current, false) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// NotModified reports whether a read with the given If-None-Match header can
+// be answered with 304 Not Modified
+func NotModified(header, current string) bool {
+	if strings.TrimSpace(header) == "" {
+		return false
+	}
+	return matches(header, current, true)
+}
+
+// Status returns the HTTP status code matching an error returned by CheckIfMatch
+func Status(err error) int {
+	if errors.Is(err, ErrPreconditionRequired) {
+		return http.StatusPreconditionRequired
+	}
+	return http.StatusPreconditionFailed
+}
+
+// matches reports whether current appears in a comma-separated list of
+// entity tags or the list is "*"
+func matches(header, current string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+
+		if tag == current {
+			return true
+		}
+	}
+	return false
+}
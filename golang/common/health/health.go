@@ -0,0 +1,196 @@
+// Package health implements the liveness and readiness probes. Liveness
+// only says the process is serving; readiness checks the dependencies a
+// request needs, so an orchestrator stops routing to an instance that
+// cannot answer instead of restarting it.
+package health
+
+import (
+	"common/lifecycle"
+	"common/migrations"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Check results
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Report is the body of the liveness and readiness probes
+type Report struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks,omitempty"`
+}
+
+// Check is the result of one readiness check
+type Check struct {
+	Status  string           `json:"status"`
+	Error   string           `json:"error,omitempty"`
+	Details map[string]int64 `json:"details,omitempty"`
+}
+
+// OK reports whether every check passed
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Config controls the readiness checks
+type Config struct {
+	// Timeout bounds the database checks of one probe
+	Timeout time.Duration
+}
+
+// DefaultConfig gives the database two seconds to answer
+var DefaultConfig = Config{Timeout: 2 * time.Second}
+
+// ConfigFromEnv reads READY_TIMEOUT, falling back to DefaultConfig
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig
+
+	if v := os.Getenv("READY_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid READY_TIMEOUT %q", v)
+		}
+		cfg.Timeout = d
+	}
+
+	return cfg, nil
+}
+
+// Checker runs the probes against a database
+type Checker struct {
+	db       *gorm.DB
+	migrator *migrations.Migrator
+	cfg      Config
+
+	// waits is the pool wait count seen by the previous probe
+	waits atomic.Int64
+}
+
+// NewChecker returns a Checker for db. The embedded migrations are loaded
+// once here instead of on every probe.
+func NewChecker(db *gorm.DB, cfg Config) (*Checker, error) {
+	// Probes run every few seconds; only log their failed and slow queries
+	quiet := db.Session(&gorm.Session{NewDB: true, Logger: db.Logger.LogMode(logger.Warn)})
+
+	m, err := migrations.New(quiet)
+	if err != nil {
+		return nil, err
+	}
+	return &Checker{db: quiet, migrator: m, cfg: cfg}, nil
+}
+
+// Live returns the liveness report. It checks no dependency: a process
+// that can answer is alive, and restarting it would not fix the database.
+func (c *Checker) Live() Report {
+	return Report{Status: StatusOK}
+}
+
+// Ready returns the readiness report. The instance is ready when it is not
+// shutting down, its connection pool is not exhausted, the database answers
+// a ping and every migration is applied.
+func (c *Checker) Ready(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	// The pool is inspected before the ping takes a connection from it
+	checks := map[string]Check{
+		"shutdown":   c.shutdown(),
+		"pool":       c.pool(),
+		"database":   c.ping(ctx),
+		"migrations": c.migrations(ctx),
+	}
+
+	report := Report{Status: StatusOK, Checks: checks}
+	for _, check := range checks {
+		if check.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// shutdown fails once the server started shutting down
+func (c *Checker) shutdown() Check {
+	if !lifecycle.Ready() {
+		return Check{Status: StatusFail, Error: "shutting down"}
+	}
+	return Check{Status: StatusOK}
+}
+
+// pool fails when every connection is in use and requests had to wait for
+// one since the previous probe
+func (c *Checker) pool() Check {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return Check{Status: StatusFail, Error: failed("pool check", err)}
+	}
+
+	stats := sqlDB.Stats()
+	check := Check{Status: StatusOK, Details: map[string]int64{
+		"max_open":   int64(stats.MaxOpenConnections),
+		"open":       int64(stats.OpenConnections),
+		"in_use":     int64(stats.InUse),
+		"idle":       int64(stats.Idle),
+		"wait_count": stats.WaitCount,
+	}}
+
+	previous := c.waits.Swap(stats.WaitCount)
+	saturated := stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections
+	if saturated && stats.WaitCount > previous {
+		check.Status = StatusFail
+		check.Error = "connection pool exhausted"
+	}
+	return check
+}
+
+// ping fails when the database does not answer within the timeout
+func (c *Checker) ping(ctx context.Context) Check {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return Check{Status: StatusFail, Error: failed("database ping", err)}
+	}
+
+	start := time.Now()
+	err = sqlDB.PingContext(ctx)
+	check := Check{Status: StatusOK, Details: map[string]int64{
+		"duration_ms": time.Since(start).Milliseconds(),
+	}}
+	if err != nil {
+		check.Status = StatusFail
+		check.Error = failed("database ping", err)
+	}
+	return check
+}
+
+// migrations fails while a migration is pending, e.g. after another
+// instance rolled one back
+func (c *Checker) migrations(ctx context.Context) Check {
+	pending, err := c.migrator.Pending(ctx)
+	if err != nil {
+		return Check{Status: StatusFail, Error: failed("migration check", err)}
+	}
+
+	check := Check{Status: StatusOK, Details: map[string]int64{"pending": int64(len(pending))}}
+	if len(pending) > 0 {
+		check.Status = StatusFail
+		check.Error = fmt.Sprintf("%d pending migration(s), starting with %s", len(pending), pending[0])
+	}
+	return check
+}
+
+// failed logs why a check failed and returns the error reported in the
+// probe. The driver error may name hosts or users, so it is only logged.
+func failed(check string, err error) string {
+	slog.Warn("Readiness check failed", "check", check, "error", err)
+	return check + " failed"
+}
@@ -0,0 +1,42 @@
+// Package testdb opens throwaway databases for the tests of other packages
+package testdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"common/database"
+	"common/migrations"
+	"common/rbac"
+
+	"gorm.io/gorm"
+)
+
+// Open returns a SQLite database in a temporary directory with every
+// migration applied and the roles seeded. It is closed when the test ends.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	db, err := database.Open(database.Config{
+		Driver:       database.DriverSQLite,
+		Name:         filepath.Join(t.TempDir(), "test.db"),
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rbac.SeedRoles(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
@@ -0,0 +1,132 @@
+// Package lifecycle runs a server until the process is asked to stop and
+// then shuts it down gracefully: readiness fails first, in-flight requests
+// drain, and the resources the server used are released.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Config controls the graceful shutdown
+type Config struct {
+	// Delay is how long the server keeps serving after readiness fails, so
+	// load balancers stop routing to it before it refuses connections
+	Delay time.Duration
+	// Timeout bounds how long in-flight requests may take to drain
+	Timeout time.Duration
+}
+
+// DefaultConfig drains for up to 30 seconds without a delay
+var DefaultConfig = Config{
+	Delay:   0,
+	Timeout: 30 * time.Second,
+}
+
+// ConfigFromEnv reads SHUTDOWN_DELAY and SHUTDOWN_TIMEOUT, falling back to
+// DefaultConfig
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig
+
+	if v := os.Getenv("SHUTDOWN_DELAY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return cfg, fmt.Errorf("invalid SHUTDOWN_DELAY %q", v)
+		}
+		cfg.Delay = d
+	}
+
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid SHUTDOWN_TIMEOUT %q", v)
+		}
+		cfg.Timeout = d
+	}
+
+	return cfg, nil
+}
+
+// Server is the HTTP server of one of the frameworks
+type Server struct {
+	// Serve listens and serves until Shutdown is called
+	Serve func() error
+	// Shutdown stops accepting connections and waits for in-flight
+	// requests until ctx is done
+	Shutdown func(ctx context.Context) error
+}
+
+var ready atomic.Bool
+
+// Ready reports whether the server should receive traffic. It is false
+// before the server starts and from the moment shutdown begins.
+func Ready() bool {
+	return ready.Load()
+}
+
+// Run serves until SIGINT or SIGTERM and then shuts the server down:
+// readiness fails, the server keeps serving for cfg.Delay, stops accepting
+// connections and drains in-flight requests for up to cfg.Timeout. The
+// cleanup functions run last, in order, e.g. to stop background jobs and
+// close the database. A second signal during the shutdown ends the process
+// immediately.
+func Run(cfg Config, server Server, cleanup ...func()) error {
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ready.Store(true)
+	served := make(chan error, 1)
+	go func() { served <- server.Serve() }()
+
+	var err error
+	select {
+	case err = <-served:
+		// The server failed to start, e.g. the port is in use
+		ready.Store(false)
+	case <-signals.Done():
+		stop()
+		err = shutdown(cfg, server, served)
+	}
+
+	for _, fn := range cleanup {
+		fn()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	return err
+}
+
+// shutdown fails readiness and drains the server
+func shutdown(cfg Config, server Server, served <-chan error) error {
+	ready.Store(false)
+	log.Printf("Shutting down: draining in-flight requests for up to %s", cfg.Timeout)
+	time.Sleep(cfg.Delay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			slog.Warn("Shutdown timed out; dropping the remaining requests", "timeout", cfg.Timeout.String())
+			return nil
+		}
+		return err
+	}
+
+	// Serve returns once the listener is closed
+	if err := <-served; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Println("Server stopped")
+	return nil
+}
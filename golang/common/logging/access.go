@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Request describes a served request for the access log
+type Request struct {
+	Method string
+	// Path is the request path without its query, which may carry secrets
+	Path string
+	// Route is the template the request matched, or "" if none did
+	Route     string
+	Status    int
+	Bytes     int
+	Duration  time.Duration
+	RemoteIP  string
+	UserAgent string
+}
+
+// Access logs a served request. Server errors are logged at error level,
+// everything else at info. ctx is the request context, so the line carries
+// the request and trace IDs.
+func Access(ctx context.Context, r Request) {
+	level := slog.LevelInfo
+	if r.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("path", r.Path),
+	}
+	if r.Route != "" {
+		attrs = append(attrs, slog.String("route", r.Route))
+	}
+	attrs = append(attrs,
+		slog.Int("status", r.Status),
+		slog.Int("bytes", r.Bytes),
+		slog.Float64("duration_ms", milliseconds(r.Duration)),
+		slog.String("remote_ip", r.RemoteIP),
+		slog.String("user_agent", r.UserAgent),
+	)
+	slog.LogAttrs(ctx, level, "request", attrs...)
+}
+
+// milliseconds returns d in milliseconds with microsecond precision
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GormLogger writes GORM's messages and queries through slog. Failed
+// queries are logged at error level and queries over the slow query
+// threshold at warn; every other query only at debug level. Statements are
+// logged with their placeholders, never with the bound values, which
+// include password hashes and tokens.
+type GormLogger struct {
+	level logger.LogLevel
+	slow  time.Duration
+}
+
+// NewGormLogger returns a GormLogger using the slow query threshold of the
+// last Init
+func NewGormLogger() *GormLogger {
+	return &GormLogger{level: logger.Info, slow: time.Duration(slowQuery.Load())}
+}
+
+// LogMode returns a copy logging at level, e.g. logger.Warn to only log the
+// failed and slow queries of a session
+func (l *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	c := *l
+	c.level = level
+	return &c
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs a query once it has run
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	duration := slog.Float64("duration_ms", milliseconds(elapsed))
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		slog.ErrorContext(ctx, "query failed", "sql", sql, "rows", rows, duration, "error", err)
+	case l.slow > 0 && elapsed > l.slow && l.level >= logger.Warn:
+		sql, rows := fc()
+		slog.WarnContext(ctx, "slow query", "sql", sql, "rows", rows, duration, "threshold_ms", milliseconds(l.slow))
+	case l.level >= logger.Info && slog.Default().Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		slog.DebugContext(ctx, "query", "sql", sql, "rows", rows, duration)
+	}
+}
+
+// ParamsFilter drops the bound values, so the statements GORM passes to
+// Trace keep their placeholders
+func (l *GormLogger) ParamsFilter(_ context.Context, sql string, _ ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -0,0 +1,124 @@
+// Package logging writes every log line as JSON through log/slog. The
+// standard log package is redirected to the same handler, records logged
+// with a request context carry its request and trace IDs, and attributes
+// that may hold credentials are redacted before they are written.
+package logging
+
+import (
+	"common/requestid"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redacted replaces the value of sensitive attributes
+const Redacted = "[REDACTED]"
+
+// Config controls what is logged
+type Config struct {
+	// Level is the lowest level written
+	Level slog.Level
+	// SlowQuery is how long a query may take before it is logged as slow;
+	// zero disables the check
+	SlowQuery time.Duration
+}
+
+// DefaultConfig logs at info level and warns about queries over 200ms
+var DefaultConfig = Config{
+	Level:     slog.LevelInfo,
+	SlowQuery: 200 * time.Millisecond,
+}
+
+// ConfigFromEnv reads LOG_LEVEL and LOG_SLOW_QUERY, falling back to
+// DefaultConfig
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig
+
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		switch strings.ToLower(v) {
+		case "debug":
+			cfg.Level = slog.LevelDebug
+		case "info":
+			cfg.Level = slog.LevelInfo
+		case "warn":
+			cfg.Level = slog.LevelWarn
+		case "error":
+			cfg.Level = slog.LevelError
+		default:
+			return cfg, fmt.Errorf("invalid LOG_LEVEL %q: must be debug, info, warn or error", v)
+		}
+	}
+
+	if v := os.Getenv("LOG_SLOW_QUERY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return cfg, fmt.Errorf("invalid LOG_SLOW_QUERY %q", v)
+		}
+		cfg.SlowQuery = d
+	}
+
+	return cfg, nil
+}
+
+var slowQuery atomic.Int64
+
+func init() {
+	slowQuery.Store(int64(DefaultConfig.SlowQuery))
+}
+
+// Init makes a JSON handler writing to stdout the default slog logger. The
+// standard log package writes through it too, at info level.
+func Init(cfg Config) {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level:       cfg.Level,
+		ReplaceAttr: redact,
+	})
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	slowQuery.Store(int64(cfg.SlowQuery))
+}
+
+// sensitive lists the key fragments of attributes whose values are never
+// written
+var sensitive = []string{"password", "secret", "token", "authorization", "cookie", "dsn"}
+
+// redact replaces the values of sensitive attributes, in groups too
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, s := range sensitive {
+		if strings.Contains(key, s) {
+			return slog.String(a.Key, Redacted)
+		}
+	}
+	return a
+}
+
+// contextHandler adds the request and trace IDs of the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := requestid.From(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
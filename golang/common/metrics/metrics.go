@@ -0,0 +1,101 @@
+// Package metrics exposes Prometheus metrics of the HTTP server and its
+// database connection pool. Each framework records requests through a small
+// middleware; the collectors and their names are shared, so dashboards work
+// for every variant.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+// Unmatched is the route label of requests no route matched. Their raw
+// paths are not used as labels, so scanners cannot create a series per URL.
+const Unmatched = "unmatched"
+
+// Metrics holds the collectors of one server
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+// New registers the HTTP collectors, the Go runtime and process collectors
+// and the connection pool statistics of db
+func New(db *gorm.DB) (*Metrics, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("metrics: %w", err)
+	}
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method, route template and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests currently being served.",
+		}),
+	}
+
+	// go_sql_* series: open, idle and in-use connections, wait count and
+	// duration, labelled with the driver name
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		m.inFlight,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(sqlDB, db.Dialector.Name()),
+	)
+	return m, nil
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Begin counts a request as in flight. The returned function records the
+// request once its response status is known; route is the template the
+// request matched, e.g. /api/users/:id, or "" if none did.
+func (m *Metrics) Begin() func(method, route string, status int) {
+	start := time.Now()
+	m.inFlight.Inc()
+
+	return func(method, route string, status int) {
+		m.inFlight.Dec()
+		labels := prometheus.Labels{"method": method, "route": Route(route), "status": strconv.Itoa(status)}
+		m.requests.With(labels).Inc()
+		m.duration.With(labels).Observe(time.Since(start).Seconds())
+	}
+}
+
+// braceParam matches a {name} or {name:pattern} path parameter
+var braceParam = regexp.MustCompile(`\{(\w+)(?::[^}]*)?\}`)
+
+// Route returns the route label of a route template. gorilla/mux templates
+// are converted to the :name form the other frameworks use, so the same
+// route has the same label everywhere.
+func Route(template string) string {
+	if template == "" {
+		return Unmatched
+	}
+	return braceParam.ReplaceAllString(template, ":$1")
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// nonWord matches what a migration name may not contain
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes empty up and down files for a new migration to every
+// dialect directory under dir and returns their paths. The version is one
+// past the highest existing one. The binary must be rebuilt to embed them.
+func Create(dir, name string) ([]string, error) {
+	name = strings.Trim(nonWord.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, fmt.Errorf("migration name must contain letters or digits")
+	}
+
+	next := 1
+	for _, d := range dialects {
+		existing, err := load(os.DirFS(dir), d.name)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(existing); n > 0 && existing[n-1].Version >= next {
+			next = existing[n-1].Version + 1
+		}
+	}
+
+	var created []string
+	for _, d := range dialects {
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, d.name, fmt.Sprintf("%04d_%s.%s.sql", next, name, direction))
+			content := fmt.Sprintf("-- %04d_%s (%s, %s)\n", next, name, d.name, direction)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				return created, err
+			}
+			created = append(created, path)
+		}
+	}
+
+	return created, nil
+}
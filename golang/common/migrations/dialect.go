@@ -0,0 +1,99 @@
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lockName identifies the migration lock among other advisory locks
+const lockName = "schema_migrations"
+
+// lockTimeoutSeconds is how long a replica waits for another one to finish migrating
+const lockTimeoutSeconds = 300
+
+// dialect holds the database specific parts of the migrator
+type dialect struct {
+	name        string
+	createTable string
+	lock        func(conn *gorm.DB) error
+	unlock      func(conn *gorm.DB) error
+}
+
+var mysql = dialect{
+	name: "mysql",
+	createTable: "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+		"`version` BIGINT NOT NULL PRIMARY KEY, " +
+		"`name` VARCHAR(255) NOT NULL, " +
+		"`applied_at` DATETIME(3) NOT NULL" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci",
+	lock: func(conn *gorm.DB) error {
+		var acquired *int
+		if err := conn.Raw("SELECT GET_LOCK(?, ?)", lockName, lockTimeoutSeconds).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if acquired == nil || *acquired != 1 {
+			return fmt.Errorf("timed out after %ds waiting for another migration", lockTimeoutSeconds)
+		}
+		return nil
+	},
+	unlock: func(conn *gorm.DB) error {
+		return conn.Exec("SELECT RELEASE_LOCK(?)", lockName).Error
+	},
+}
+
+var postgres = dialect{
+	name: "postgres",
+	createTable: "CREATE TABLE IF NOT EXISTS schema_migrations (" +
+		"version BIGINT NOT NULL PRIMARY KEY, " +
+		"name VARCHAR(255) NOT NULL, " +
+		"applied_at TIMESTAMPTZ NOT NULL" +
+		")",
+	// pg_advisory_lock would wait forever, so poll until the timeout instead
+	lock: func(conn *gorm.DB) error {
+		deadline := time.Now().Add(lockTimeoutSeconds * time.Second)
+		for {
+			var acquired bool
+			if err := conn.Raw("SELECT pg_try_advisory_lock(hashtext(?))", lockName).Scan(&acquired).Error; err != nil {
+				return err
+			}
+			if acquired {
+				return nil
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timed out after %ds waiting for another migration", lockTimeoutSeconds)
+			}
+			time.Sleep(time.Second)
+		}
+	},
+	unlock: func(conn *gorm.DB) error {
+		return conn.Exec("SELECT pg_advisory_unlock(hashtext(?))", lockName).Error
+	},
+}
+
+// SQLite databases are local files that replicas don't share, and a
+// migration's transaction already holds the database's write lock
+var sqlite = dialect{
+	name: "sqlite",
+	createTable: "CREATE TABLE IF NOT EXISTS schema_migrations (" +
+		"version INTEGER NOT NULL PRIMARY KEY, " +
+		"name VARCHAR(255) NOT NULL, " +
+		"applied_at DATETIME NOT NULL" +
+		")",
+	lock:   func(*gorm.DB) error { return nil },
+	unlock: func(*gorm.DB) error { return nil },
+}
+
+// dialects are the databases migrations are written for
+var dialects = []dialect{mysql, postgres, sqlite}
+
+// dialectOf returns the dialect matching the driver of db
+func dialectOf(db *gorm.DB) (dialect, error) {
+	for _, d := range dialects {
+		if d.name == db.Dialector.Name() {
+			return d, nil
+		}
+	}
+	return dialect{}, fmt.Errorf("migrations are not available for %s", db.Dialector.Name())
+}
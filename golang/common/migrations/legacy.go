@@ -0,0 +1,186 @@
+package migrations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// legacyUser is the users table as of the first migration. It is frozen
+// here, independent of models.User, so adopting an old table always
+// produces the same schema.
+type legacyUser struct {
+	Email     string `gorm:"uniqueIndex:idx_users_email_deleted_at,priority:1"`
+	Username  string `gorm:"uniqueIndex:idx_users_username_deleted_at,priority:1"`
+	Version   uint   `gorm:"not null;default:1"`
+	DeletedAt uint   `gorm:"column:deleted_at;not null;default:0;index;uniqueIndex:idx_users_email_deleted_at,priority:2;uniqueIndex:idx_users_username_deleted_at,priority:2"`
+}
+
+// TableName specifies the table name for the legacyUser model
+func (legacyUser) TableName() string {
+	return "users"
+}
+
+// legacyUniqueIndexes are the single-column unique indexes created before
+// email and username became unique per deleted_at
+var legacyUniqueIndexes = []string{"email", "username", "uni_users_email", "uni_users_username", "uk_email", "uk_username"}
+
+// mysqlColumns are the types the first migration gives the users columns
+// that database-setup.sql used to declare differently. user_roles.user_id
+// references id, and MySQL only accepts a foreign key between integer
+// columns of the same size and sign.
+var mysqlColumns = []struct {
+	name       string
+	typ        string
+	nullable   bool
+	definition string
+}{
+	{"id", "bigint unsigned", false, "BIGINT UNSIGNED NOT NULL AUTO_INCREMENT"},
+	{"created_at", "datetime(3)", true, "DATETIME(3) NULL"},
+	{"updated_at", "datetime(3)", true, "DATETIME(3) NULL"},
+}
+
+// displayWidth matches the display width MariaDB and older MySQL versions
+// report for integer types, e.g. int(11)
+var displayWidth = regexp.MustCompile(`int\(\d+\)`)
+
+// adoptLegacySchema brings a users table created by AutoMigrate or
+// database-setup.sql up to the first migration: deleted_at becomes unix
+// seconds, version is added, the unique indexes are rebuilt per deleted_at
+// and on MySQL the column types are aligned. It does nothing on a new
+// database.
+func adoptLegacySchema(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&legacyUser{}) {
+		return nil
+	}
+
+	for _, name := range legacyUniqueIndexes {
+		if m.HasIndex(&legacyUser{}, name) {
+			if err := m.DropIndex(&legacyUser{}, name); err != nil {
+				return fmt.Errorf("failed to drop index %s: %v", name, err)
+			}
+		}
+	}
+
+	if err := convertDeletedAt(db); err != nil {
+		return err
+	}
+	if err := convertMySQLColumns(db); err != nil {
+		return err
+	}
+
+	for _, column := range []string{"Version", "DeletedAt"} {
+		if !m.HasColumn(&legacyUser{}, column) {
+			if err := m.AddColumn(&legacyUser{}, column); err != nil {
+				return fmt.Errorf("failed to add column %s: %v", column, err)
+			}
+		}
+	}
+
+	for _, name := range []string{"idx_users_deleted_at", "idx_users_email_deleted_at", "idx_users_username_deleted_at"} {
+		if !m.HasIndex(&legacyUser{}, name) {
+			if err := m.CreateIndex(&legacyUser{}, name); err != nil {
+				return fmt.Errorf("failed to create index %s: %v", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// convertDeletedAt converts a nullable DATETIME deleted_at to unix seconds
+func convertDeletedAt(db *gorm.DB) error {
+	m := db.Migrator()
+
+	columns, err := m.ColumnTypes(&legacyUser{})
+	if err != nil {
+		return fmt.Errorf("failed to read users columns: %v", err)
+	}
+
+	legacy := false
+	for _, col := range columns {
+		if col.Name() == "deleted_at" {
+			typ := strings.ToLower(col.DatabaseTypeName())
+			legacy = strings.Contains(typ, "date") || strings.Contains(typ, "time")
+		}
+	}
+	if !legacy {
+		return nil
+	}
+
+	var unixExpr string
+	switch db.Dialector.Name() {
+	case "mysql":
+		unixExpr = "UNIX_TIMESTAMP(deleted_at)"
+	case "postgres":
+		unixExpr = "CAST(EXTRACT(EPOCH FROM deleted_at) AS BIGINT)"
+	case "sqlite":
+		unixExpr = "CAST(strftime('%s', deleted_at) AS INTEGER)"
+	default:
+		return fmt.Errorf("cannot convert deleted_at on %s", db.Dialector.Name())
+	}
+
+	// Copy into a new column, then swap it in under the old name. The copy
+	// is repeated if a previous run stopped half-way.
+	if !m.HasColumn(&legacyUser{}, "deleted_at_unix") {
+		if err := db.Exec("ALTER TABLE users ADD COLUMN deleted_at_unix BIGINT NOT NULL DEFAULT 0").Error; err != nil {
+			return fmt.Errorf("failed to add deleted_at_unix: %v", err)
+		}
+	}
+	if err := db.Exec("UPDATE users SET deleted_at_unix = " + unixExpr + " WHERE deleted_at IS NOT NULL").Error; err != nil {
+		return fmt.Errorf("failed to convert deleted_at: %v", err)
+	}
+
+	if m.HasIndex(&legacyUser{}, "idx_users_deleted_at") {
+		if err := m.DropIndex(&legacyUser{}, "idx_users_deleted_at"); err != nil {
+			return fmt.Errorf("failed to drop index idx_users_deleted_at: %v", err)
+		}
+	}
+	if err := db.Exec("ALTER TABLE users DROP COLUMN deleted_at").Error; err != nil {
+		return fmt.Errorf("failed to drop deleted_at: %v", err)
+	}
+	if err := m.RenameColumn(&legacyUser{}, "deleted_at_unix", "deleted_at"); err != nil {
+		return fmt.Errorf("failed to rename deleted_at_unix: %v", err)
+	}
+
+	return nil
+}
+
+// convertMySQLColumns changes the users columns listed in mysqlColumns to
+// the type of the first migration. Columns that already match are left
+// alone, so a table created by AutoMigrate is not rebuilt.
+func convertMySQLColumns(db *gorm.DB) error {
+	if db.Dialector.Name() != "mysql" {
+		return nil
+	}
+
+	columns, err := db.Migrator().ColumnTypes(&legacyUser{})
+	if err != nil {
+		return fmt.Errorf("failed to read users columns: %v", err)
+	}
+	current := make(map[string]gorm.ColumnType, len(columns))
+	for _, col := range columns {
+		current[col.Name()] = col
+	}
+
+	for _, column := range mysqlColumns {
+		col, ok := current[column.name]
+		if !ok {
+			continue
+		}
+		typ, _ := col.ColumnType()
+		typ = displayWidth.ReplaceAllString(strings.ToLower(typ), "int")
+		nullable, _ := col.Nullable()
+		if typ == column.typ && nullable == column.nullable {
+			continue
+		}
+
+		if err := db.Exec("ALTER TABLE users MODIFY COLUMN " + column.name + " " + column.definition).Error; err != nil {
+			return fmt.Errorf("failed to change the type of %s: %v", column.name, err)
+		}
+	}
+	return nil
+}
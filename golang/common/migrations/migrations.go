@@ -0,0 +1,371 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"log/slog"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// fileName matches migration files such as 0001_create_users.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// String returns the migration's file name prefix, e.g. 0001_create_users
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status is a migration together with when it was applied
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of the schema_migrations table
+type schemaMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrator applies the embedded migrations of the database's dialect
+type Migrator struct {
+	db         *gorm.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+// New loads the migrations matching the dialect of db
+func New(db *gorm.DB) (*Migrator, error) {
+	d, err := dialectOf(db)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := load(files, d.name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, dialect: d, migrations: migrations}, nil
+}
+
+// load reads and pairs the up/down files of one dialect directory
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s: %v", dir, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s/%s", dir, entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %04d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			m.up = string(data)
+		} else {
+			m.down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %s needs both an up and a down file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns those applied
+func (m *Migrator) Up() ([]Migration, error) {
+	var applied []Migration
+
+	err := m.locked(func(conn *gorm.DB) error {
+		done, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		// Tables created before migrations existed are brought up to the
+		// first migration, whose CREATE TABLE IF NOT EXISTS then skips them
+		if len(done) == 0 {
+			if err := adoptLegacySchema(conn); err != nil {
+				return fmt.Errorf("failed to adopt existing schema: %v", err)
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			err := m.run(conn, migration.up, func(tx *gorm.DB) error {
+				return tx.Create(&schemaMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					AppliedAt: time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %s failed: %v", migration, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the last steps applied migrations and returns those rolled back
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var rolledBack []Migration
+
+	err := m.locked(func(conn *gorm.DB) error {
+		done, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			err := m.run(conn, migration.down, func(tx *gorm.DB) error {
+				return tx.Where("version = ?", migration.Version).Delete(&schemaMigration{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of %s failed: %v", migration, err)
+			}
+			rolledBack = append(rolledBack, migration)
+		}
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status lists every migration and when it was applied
+func (m *Migrator) Status() ([]Status, error) {
+	if err := m.ensureTable(m.db); err != nil {
+		return nil, err
+	}
+
+	done, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if row, ok := done[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet. It only
+// reads schema_migrations, so readiness probes can call it; a missing table
+// means no migration has been applied.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	db := m.db.WithContext(ctx)
+
+	var versions []int
+	if db.Migrator().HasTable(&schemaMigration{}) {
+		if err := db.Model(&schemaMigration{}).Pluck("version", &versions).Error; err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+		}
+	}
+
+	done := make(map[int]bool, len(versions))
+	for _, version := range versions {
+		done[version] = true
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// locked runs fn on a single connection holding the migration lock, so
+// replicas starting together apply migrations one at a time
+func (m *Migrator) locked(fn func(conn *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		// Start every statement afresh while staying on this connection
+		conn = conn.Session(&gorm.Session{NewDB: true})
+
+		if err := m.dialect.lock(conn); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %v", err)
+		}
+		defer func() {
+			if err := m.dialect.unlock(conn); err != nil {
+				slog.Error("Failed to release migration lock", "dialect", m.db.Dialector.Name(), "error", err)
+			}
+		}()
+
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// run executes the statements of a migration file and records the result
+// in one transaction. Note that MySQL commits DDL statements implicitly.
+func (m *Migrator) run(conn *gorm.DB, sql string, record func(tx *gorm.DB) error) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range splitStatements(sql) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return record(tx)
+	})
+}
+
+func (m *Migrator) ensureTable(db *gorm.DB) error {
+	if err := db.Exec(m.dialect.createTable).Error; err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied(db *gorm.DB) (map[int]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+
+	done := make(map[int]schemaMigration, len(rows))
+	for _, row := range rows {
+		done[row.Version] = row
+	}
+	return done, nil
+}
+
+// TableName specifies the table name for the schemaMigration model
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// splitStatements splits a migration file into statements ending with ";"
+// at the end of a line. Comment-only lines are dropped.
+func splitStatements(sql string) []string {
+	var stmts []string
+	var current strings.Builder
+
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+
+	return stmts
+}
+
+// OnStartFromEnv reads MIGRATE_ON_START, which is true unless set
+func OnStartFromEnv() (bool, error) {
+	v := os.Getenv("MIGRATE_ON_START")
+	if v == "" {
+		return true, nil
+	}
+
+	onStart, err := strconv.ParseBool(v)
+	if err != nil {
+		return true, fmt.Errorf("invalid MIGRATE_ON_START %q: %v", v, err)
+	}
+	return onStart, nil
+}
+
+// ApplyOnStart applies pending migrations when the server starts.
+// Without onStart (MIGRATE_ON_START=false) it only refuses to start while
+// any are pending, leaving them to the "migrate up" command.
+func ApplyOnStart(db *gorm.DB, onStart bool) error {
+	m, err := New(db)
+	if err != nil {
+		return err
+	}
+
+	if !onStart {
+		pending, err := m.Pending(context.Background())
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migration(s), starting with %s; run \"migrate up\"", len(pending), pending[0])
+		}
+		return nil
+	}
+
+	applied, err := m.Up()
+	for _, migration := range applied {
+		log.Printf("Applied migration %s", migration)
+	}
+	return err
+}
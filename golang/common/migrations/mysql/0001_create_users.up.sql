@@ -0,0 +1,16 @@
+-- Users. deleted_at holds the unix time of a soft delete (0 = active), so
+-- email and username are unique per deleted_at.
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `email` VARCHAR(255) NOT NULL,
+  `username` VARCHAR(50) NOT NULL,
+  `password` VARCHAR(255) NOT NULL,
+  `version` BIGINT UNSIGNED NOT NULL DEFAULT 1,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  `deleted_at` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_users_email_deleted_at` (`email`, `deleted_at`),
+  UNIQUE KEY `idx_users_username_deleted_at` (`username`, `deleted_at`),
+  KEY `idx_users_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
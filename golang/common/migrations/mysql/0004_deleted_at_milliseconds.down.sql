@@ -0,0 +1,2 @@
+-- Fails if two copies of an email or username were deleted within one second
+UPDATE users SET deleted_at = deleted_at DIV 1000 WHERE deleted_at <> 0;
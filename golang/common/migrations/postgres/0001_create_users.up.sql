@@ -0,0 +1,17 @@
+-- Users. deleted_at holds the unix time of a soft delete (0 = active), so
+-- email and username are unique per deleted_at. Uniqueness ignores case as
+-- it does under the MySQL collation.
+CREATE TABLE IF NOT EXISTS users (
+  id BIGSERIAL PRIMARY KEY,
+  email VARCHAR(255) NOT NULL,
+  username VARCHAR(50) NOT NULL,
+  password VARCHAR(255) NOT NULL,
+  version BIGINT NOT NULL DEFAULT 1,
+  created_at TIMESTAMPTZ NULL,
+  updated_at TIMESTAMPTZ NULL,
+  deleted_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_deleted_at ON users (LOWER(email), deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_deleted_at ON users (LOWER(username), deleted_at);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
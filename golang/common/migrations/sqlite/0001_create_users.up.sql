@@ -0,0 +1,17 @@
+-- Users. deleted_at holds the unix time of a soft delete (0 = active), so
+-- email and username are unique per deleted_at. NOCASE makes comparisons
+-- ignore case as they do under the MySQL collation.
+CREATE TABLE IF NOT EXISTS users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email VARCHAR(255) NOT NULL COLLATE NOCASE,
+  username VARCHAR(50) NOT NULL COLLATE NOCASE,
+  password VARCHAR(255) NOT NULL,
+  version INTEGER NOT NULL DEFAULT 1,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  deleted_at INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_deleted_at ON users (email, deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_deleted_at ON users (username, deleted_at);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
@@ -0,0 +1,3 @@
+-- deleted_at holds unix milliseconds instead of seconds, so a user deleted
+-- twice within one second does not collide in the unique indexes
+UPDATE users SET deleted_at = deleted_at * 1000 WHERE deleted_at <> 0;
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RefreshToken represents the refresh_tokens table.
+// Only the SHA-256 hash of a token is stored. Tokens issued by rotating
+// one another share a FamilyID so a reused token can revoke the whole chain.
+type RefreshToken struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement"`
+	UserID     uint       `gorm:"not null;index"`
+	FamilyID   string     `gorm:"type:char(32);not null;index"`
+	TokenHash  string     `gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt  time.Time  `gorm:"not null"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+	ReplacedBy *uint      `gorm:"column:replaced_by"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+}
+
+// TableName specifies the table name for the RefreshToken model
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Role represents the roles table. Users and roles are linked through
+// the user_roles join table.
+type Role struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	Name        string    `gorm:"type:varchar(50);not null;unique"`
+	Description string    `gorm:"type:varchar(255);not null;default:''"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+}
+
+// TableName specifies the table name for the Role model
+func (Role) TableName() string {
+	return "roles"
+}
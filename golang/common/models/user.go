@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+// User represents the users table.
+//
+// DeletedAt holds the unix time in milliseconds of a soft delete and 0 for
+// active users, so
+// email and username only need to be unique among rows with the same
+// deleted_at and a soft-deleted user does not block re-registration.
+// Milliseconds keep two copies of an email deleted within one second apart.
+type User struct {
+	ID        uint                  `gorm:"primaryKey;autoIncrement"`
+	Email     string                `gorm:"type:varchar(255);not null;uniqueIndex:idx_users_email_deleted_at,priority:1"`
+	Username  string                `gorm:"type:varchar(50);not null;uniqueIndex:idx_users_username_deleted_at,priority:1"`
+	Password  string                `gorm:"type:varchar(255);not null"`
+	Version   uint                  `gorm:"not null;default:1"`
+	CreatedAt time.Time             `gorm:"column:created_at"`
+	UpdatedAt time.Time             `gorm:"column:updated_at"`
+	DeletedAt soft_delete.DeletedAt `gorm:"column:deleted_at;softDelete:milli;not null;default:0;index;uniqueIndex:idx_users_email_deleted_at,priority:2;uniqueIndex:idx_users_username_deleted_at,priority:2"`
+	Roles     []Role                `gorm:"many2many:user_roles;constraint:OnDelete:CASCADE"`
+}
+
+// TableName specifies the table name for the User model
+func (User) TableName() string {
+	return "users"
+}
+
+// IsDeleted reports whether the user is in the trash
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != 0
+}
+
+// DeletedTime returns when the user was soft-deleted, or nil for active users
+func (u *User) DeletedTime() *time.Time {
+	if !u.IsDeleted() {
+		return nil
+	}
+	t := time.UnixMilli(int64(u.DeletedAt))
+	return &t
+}
+
+// RoleNames returns the names of the user's roles
+func (u *User) RoleNames() []string {
+	names := make([]string, 0, len(u.Roles))
+	for _, role := range u.Roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
@@ -0,0 +1,51 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"common/problem"
+	"common/requestid"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// docsAssets are the Swagger UI files docs.html loads
+var docsAssets = map[string]bool{
+	"swagger-ui.css":                  true,
+	"swagger-ui-bundle.js":            true,
+	"swagger-ui-standalone-preset.js": true,
+	"favicon-32x32.png":               true,
+}
+
+// DocsAsset reports whether /docs/{name} is one of the Swagger UI files
+func DocsAsset(name string) bool {
+	return docsAssets[name]
+}
+
+// DocsHandler serves the Swagger UI page at /docs and the files it loads at
+// /docs/{file}; any other file is answered with a not_found problem. The page
+// loads the document from /openapi.json.
+func DocsHandler() http.Handler {
+	assets := http.StripPrefix("/docs", http.FileServer(http.FS(swaggerFiles.FS)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.TrimSuffix(r.URL.Path, "/") == "/docs":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(docsPage)
+		case DocsAsset(strings.TrimPrefix(r.URL.Path, "/docs/")):
+			assets.ServeHTTP(w, r)
+		default:
+			p := problem.FromStatus(http.StatusNotFound).ForRequest(r.Method, r.URL.Path, requestid.From(r.Context()))
+			w.Header().Set("Content-Type", problem.MediaType)
+			w.WriteHeader(p.Status)
+			json.NewEncoder(w).Encode(p)
+		}
+	})
+}
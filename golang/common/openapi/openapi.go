@@ -0,0 +1,268 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version of the generated document
+const Version = "3.1.0"
+
+// Route is a method and path registered with a router. Path parameters may
+// be written :id (gin, echo, fiber) or {id} (gorilla/mux).
+type Route struct {
+	Method string
+	Path   string
+}
+
+// documentedMethods are the methods that become operations. Routers also
+// report HEAD and OPTIONS routes they add on their own.
+var documentedMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// colonParam matches a :name path parameter
+var colonParam = regexp.MustCompile(`:(\w+)`)
+
+// Path converts a route path to its OpenAPI form, e.g. /api/users/:id to
+// /api/users/{id}
+func Path(path string) string {
+	return colonParam.ReplaceAllString(path, "{$1}")
+}
+
+// Document is an OpenAPI 3.1 document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components components                       `json:"components"`
+}
+
+// Info is the info object of the document
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag groups operations in the documentation UI
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string                  `json:"description"`
+	Headers     map[string]headerObject `json:"headers,omitempty"`
+	Content     map[string]mediaType    `json:"content,omitempty"`
+}
+
+type headerObject struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Build generates the OpenAPI document of the registered routes, using the
+// operation described in Operations for each of them. Registered routes
+// without a description are listed as undocumented, so the document never
+// omits what the server actually serves.
+func Build(routes []Route) ([]byte, error) {
+	described := make(map[string]*Operation, len(Operations))
+	for i := range Operations {
+		op := &Operations[i]
+		described[op.Method+" "+op.Path] = op
+	}
+
+	gen := newGenerator()
+	doc := Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Tags:       tags,
+		Paths:      map[string]map[string]*operation{},
+		Components: components{Schemas: gen.schemas, SecuritySchemes: map[string]securityScheme{}},
+	}
+
+	for _, route := range routes {
+		method := strings.ToUpper(route.Method)
+		if !documentedMethods[method] {
+			continue
+		}
+		path := Path(route.Path)
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = map[string]*operation{}
+			doc.Paths[path] = item
+		}
+		key := strings.ToLower(method)
+		if _, ok := item[key]; ok {
+			continue
+		}
+
+		op, ok := described[method+" "+path]
+		if !ok {
+			item[key] = undocumented()
+			continue
+		}
+
+		built, err := gen.operation(op)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %v", method, path, err)
+		}
+		if built.Security != nil {
+			doc.Components.SecuritySchemes[bearerAuth] = securityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+		}
+		item[key] = built
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// bearerAuth is the name of the security scheme of authenticated operations
+const bearerAuth = "bearerAuth"
+
+// undocumented is the operation of a route missing from Operations
+func undocumented() *operation {
+	return &operation{
+		Summary:   "Undocumented route",
+		Responses: map[string]*response{"default": {Description: "Not described in common/openapi"}},
+	}
+}
+
+// operation builds the operation object of a described operation
+func (g *generator) operation(op *Operation) (*operation, error) {
+	out := &operation{
+		Tags:        []string{op.Tag},
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: op.ID,
+		Responses:   map[string]*response{},
+	}
+	if op.Permission != "" {
+		out.Description = strings.TrimSpace(out.Description + "\n\n필요 권한: `" + string(op.Permission) + "`")
+	}
+	if !op.Public {
+		out.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+
+	for _, name := range pathParams(op.Path) {
+		p, ok := pathParameters[name]
+		if !ok {
+			p = parameter{Schema: &Schema{Type: "string"}}
+		}
+		p.Name, p.In, p.Required = name, "path", true
+		out.Parameters = append(out.Parameters, p)
+	}
+	for _, p := range op.Params {
+		out.Parameters = append(out.Parameters, parameter{
+			Name: p.Name, In: p.In, Required: p.Required, Description: p.Description, Schema: p.Schema,
+		})
+	}
+	if op.List != nil {
+		out.Parameters = append(out.Parameters, listParams(op.List)...)
+	}
+
+	if len(op.Request) > 0 {
+		out.RequestBody = &requestBody{Required: true, Content: map[string]mediaType{}}
+		for _, body := range op.Request {
+			schema, err := g.schemaOf(body.Value, request)
+			if err != nil {
+				return nil, err
+			}
+			out.RequestBody.Content[body.MediaType] = mediaType{Schema: schema}
+		}
+	}
+
+	for _, r := range op.Responses {
+		res := &response{Description: r.Description}
+		for _, name := range r.Headers {
+			if res.Headers == nil {
+				res.Headers = map[string]headerObject{}
+			}
+			res.Headers[name] = headerObject{Description: headerDescriptions[name], Schema: &Schema{Type: "string"}}
+		}
+		if r.Body != nil {
+			schema, err := g.schemaOf(r.Body, responseMode)
+			if err != nil {
+				return nil, err
+			}
+			mt := r.MediaType
+			if mt == "" {
+				mt = "application/json"
+			}
+			res.Content = map[string]mediaType{mt: {Schema: schema}}
+		}
+		out.Responses[strconv.Itoa(r.Status)] = res
+	}
+	return out, nil
+}
+
+// pathParams returns the parameter names of an OpenAPI path
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, segment[1:len(segment)-1])
+		}
+	}
+	return names
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,457 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"common/dto"
+	"common/health"
+	"common/patch"
+	"common/problem"
+	"common/query"
+	"common/rbac"
+)
+
+// Operation describes one API operation. Build looks it up by Method and
+// Path for every registered route.
+type Operation struct {
+	Method string
+	// Path is the OpenAPI form of the route, e.g. /api/users/{id}
+	Path        string
+	ID          string
+	Tag         string
+	Summary     string
+	Description string
+	// Public operations need no access token
+	Public     bool
+	Permission rbac.Permission
+	Params     []Param
+	// List adds the page, sort and filter parameters of a list spec
+	List      *query.Spec
+	Request   []Body
+	Responses []Response
+}
+
+// Param is a header or query parameter
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+// Body is a request body of one media type. Value is a value of the Go type
+// the body decodes into.
+type Body struct {
+	MediaType string
+	Value     interface{}
+}
+
+// Response is a documented response. Body is a value of the Go type that is
+// encoded as the response, or nil for responses without content.
+type Response struct {
+	Status      int
+	Description string
+	// MediaType defaults to application/json
+	MediaType string
+	Body      interface{}
+	Headers   []string
+}
+
+var info = Info{
+	Title:       "User CRUD API",
+	Description: "gin-gorm, echo-gorm, fiber-gorm, gorilla-gorm 서버가 등록한 라우트와 요청/응답 DTO에서 생성한 문서입니다.",
+	Version:     "1.0.0",
+}
+
+var tags = []Tag{
+	{Name: "Health", Description: "서버 상태 확인"},
+	{Name: "Metrics", Description: "Prometheus 메트릭"},
+	{Name: "Docs", Description: "API 문서"},
+	{Name: "Auth", Description: "로그인 및 토큰 관리 API"},
+	{Name: "Users", Description: "사용자 관리 API"},
+	{Name: "Roles", Description: "역할 조회 및 지정 API"},
+}
+
+// Response bodies. Handlers build these as maps; the types here describe the
+// resulting JSON.
+type (
+	messageBody struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+	userBody struct {
+		Success bool             `json:"success"`
+		Data    dto.UserResponse `json:"data"`
+	}
+	userListBody struct {
+		Success bool               `json:"success"`
+		Data    []dto.UserResponse `json:"data"`
+		Meta    query.Meta         `json:"meta"`
+	}
+	tokenBody struct {
+		Success bool              `json:"success"`
+		Data    dto.TokenResponse `json:"data"`
+	}
+	rolesBody struct {
+		Success bool               `json:"success"`
+		Data    []dto.RoleResponse `json:"data"`
+	}
+	userRolesBody struct {
+		Success bool                  `json:"success"`
+		Data    dto.UserRolesResponse `json:"data"`
+	}
+	revokedSessions struct {
+		Revoked int64 `json:"revoked"`
+	}
+	revokedSessionsBody struct {
+		Success bool            `json:"success"`
+		Message string          `json:"message"`
+		Data    revokedSessions `json:"data"`
+	}
+	healthBody struct {
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	}
+)
+
+// Request bodies of PATCH /api/users/:id
+type (
+	userPatch struct {
+		Email    *string `json:"email,omitempty" validate:"omitempty,email,max=255"`
+		Username *string `json:"username,omitempty" validate:"omitempty,min=3,max=50,username"`
+		Password *string `json:"password,omitempty" validate:"omitempty,min=8,max=128,password"`
+	}
+	jsonPatchOperation struct {
+		Op    string      `json:"op" validate:"required,oneof=add remove replace move copy test"`
+		Path  string      `json:"path" validate:"required"`
+		From  string      `json:"from,omitempty"`
+		Value interface{} `json:"value,omitempty"`
+	}
+)
+
+// pathParameters describe the path parameters by name
+var pathParameters = map[string]parameter{
+	"id":   {Description: "사용자 ID", Schema: &Schema{Type: "integer", Minimum: float(1)}},
+	"file": {Description: "Swagger UI 파일 이름", Schema: &Schema{Type: "string"}},
+}
+
+// headerDescriptions describe the documented response headers
+var headerDescriptions = map[string]string{
+	"ETag":         `사용자 버전의 강한 ETag (예: "1-3")`,
+	"Link":         "next/prev/first/last 페이지 링크 (RFC 8288)",
+	"Accept-Patch": "지원하는 패치 형식",
+	"Retry-After":  "다시 시도하기까지 기다릴 초",
+}
+
+var (
+	ifMatch = Param{
+		Name: "If-Match", In: "header",
+		Description: "조회 응답의 ETag. 현재 ETag와 다르면 412",
+		Schema:      &Schema{Type: "string"},
+	}
+	ifNoneMatch = Param{
+		Name: "If-None-Match", In: "header",
+		Description: "이전 응답의 ETag. 현재 ETag와 같으면 304",
+		Schema:      &Schema{Type: "string"},
+	}
+	hard = Param{
+		Name: "hard", In: "query",
+		Description: "영구 삭제 여부",
+		Schema:      &Schema{Type: "boolean", Default: false},
+	}
+)
+
+// fail documents an error response, an RFC 7807 problem
+func fail(status int, description string) Response {
+	return Response{Status: status, Description: description, MediaType: problem.MediaType, Body: problem.Problem{}}
+}
+
+// Error responses shared by many operations
+var (
+	unauthorized  = fail(http.StatusUnauthorized, "인증 필요 (토큰 없음/만료/무효)")
+	forbidden     = fail(http.StatusForbidden, "권한 없음")
+	invalidID     = fail(http.StatusBadRequest, "잘못된 요청 (잘못된 ID 등)")
+	notFound      = fail(http.StatusNotFound, "사용자를 찾을 수 없음")
+	conflict      = fail(http.StatusConflict, "이메일 또는 사용자명 중복")
+	stale         = fail(http.StatusPreconditionFailed, "If-Match가 현재 ETag와 다름")
+	missingMatch  = fail(http.StatusPreconditionRequired, "If-Match 필요 (REQUIRE_IF_MATCH=true)")
+	badList       = fail(http.StatusBadRequest, "잘못된 페이지/정렬/필터 파라미터")
+	badBody       = fail(http.StatusBadRequest, "잘못된 요청")
+	badCredential = fail(http.StatusUnauthorized, "리프레시 토큰이 만료/폐기/재사용됨")
+	tooLarge      = fail(http.StatusRequestEntityTooLarge, "요청 본문이 너무 큼 (MAX_BODY_SIZE)")
+	unavailable   = Response{
+		Status: http.StatusServiceUnavailable, Description: "데이터베이스 장애로 즉시 실패 (서킷 브레이커 열림)",
+		MediaType: problem.MediaType, Body: problem.Problem{}, Headers: []string{"Retry-After"},
+	}
+)
+
+// Operations describes every route of the API
+var Operations = []Operation{
+	{
+		Method: http.MethodGet, Path: "/", ID: "health", Tag: "Health", Public: true,
+		Summary:     "서버 상태 확인",
+		Description: "서버가 실행 중인지 확인합니다.",
+		Responses:   []Response{{Status: http.StatusOK, Description: "서버 실행 중", Body: healthBody{}}},
+	},
+	{
+		Method: http.MethodGet, Path: "/healthz", ID: "liveness", Tag: "Health", Public: true,
+		Summary:     "생존 확인 (liveness)",
+		Description: "프로세스가 요청에 응답할 수 있는지 확인합니다. 의존성은 확인하지 않습니다.",
+		Responses:   []Response{{Status: http.StatusOK, Description: "프로세스 실행 중", Body: health.Report{}}},
+	},
+	{
+		Method: http.MethodGet, Path: "/readyz", ID: "readiness", Tag: "Health", Public: true,
+		Summary: "준비 상태 확인 (readiness)",
+		Description: "종료 중 여부, 연결 풀 포화, 데이터베이스 ping(READY_TIMEOUT), 대기 중인 마이그레이션을 확인하고 " +
+			"항목별 결과를 응답합니다. 하나라도 실패하면 503입니다.",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "트래픽 수신 가능", Body: health.Report{}},
+			{Status: http.StatusServiceUnavailable, Description: "준비되지 않음 (실패한 항목 포함)", Body: health.Report{}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/metrics", ID: "metrics", Tag: "Metrics", Public: true,
+		Summary: "Prometheus 메트릭",
+		Description: "라우트 템플릿별 요청 수(http_requests_total)와 지연 시간(http_request_duration_seconds), " +
+			"처리 중인 요청 수(http_requests_in_flight), 데이터베이스 연결 풀 통계(go_sql_*)를 Prometheus 텍스트 형식으로 응답합니다.",
+		Responses: []Response{{Status: http.StatusOK, Description: "Prometheus 텍스트 형식 메트릭", MediaType: "text/plain", Body: ""}},
+	},
+	{
+		Method: http.MethodGet, Path: "/openapi.json", ID: "getOpenAPI", Tag: "Docs", Public: true,
+		Summary:     "OpenAPI 문서",
+		Description: "이 문서를 OpenAPI 3.1 JSON으로 응답합니다.",
+		Responses:   []Response{{Status: http.StatusOK, Description: "OpenAPI 문서", Body: map[string]interface{}{}}},
+	},
+	{
+		Method: http.MethodGet, Path: "/docs", ID: "getDocs", Tag: "Docs", Public: true,
+		Summary:     "API 문서 (Swagger UI)",
+		Description: "/openapi.json을 표시하는 Swagger UI 페이지입니다.",
+		Responses:   []Response{{Status: http.StatusOK, Description: "HTML 페이지", MediaType: "text/html", Body: ""}},
+	},
+	{
+		Method: http.MethodGet, Path: "/docs/{file}", ID: "getDocsAsset", Tag: "Docs", Public: true,
+		Summary: "Swagger UI 정적 파일",
+		Description: "Swagger UI 페이지가 불러오는 내장 파일(swagger-ui.css, swagger-ui-bundle.js, " +
+			"swagger-ui-standalone-preset.js, favicon-32x32.png)입니다. 그 밖의 파일은 404입니다.",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "정적 파일", MediaType: "*/*", Body: ""},
+			fail(http.StatusNotFound, "없거나 페이지가 쓰지 않는 파일"),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/auth/login", ID: "login", Tag: "Auth", Public: true,
+		Summary:     "로그인",
+		Description: "이메일 또는 사용자명과 비밀번호로 액세스 토큰과 리프레시 토큰을 발급합니다.",
+		Request:     []Body{{MediaType: "application/json", Value: dto.LoginRequest{}}},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "로그인 성공", Body: tokenBody{}},
+			badBody,
+			fail(http.StatusUnauthorized, "이메일/사용자명 또는 비밀번호가 틀림"),
+			tooLarge, unavailable,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/auth/refresh", ID: "refresh", Tag: "Auth", Public: true,
+		Summary:     "토큰 재발급",
+		Description: "리프레시 토큰을 교체하고 새 토큰 쌍을 발급합니다.",
+		Request:     []Body{{MediaType: "application/json", Value: dto.RefreshRequest{}}},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "재발급 성공", Body: tokenBody{}},
+			badBody, badCredential, tooLarge, unavailable,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/auth/logout", ID: "logout", Tag: "Auth", Public: true,
+		Summary:     "로그아웃",
+		Description: "리프레시 토큰을 폐기합니다.",
+		Request:     []Body{{MediaType: "application/json", Value: dto.RefreshRequest{}}},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "로그아웃 성공", Body: messageBody{}},
+			badBody, badCredential, tooLarge, unavailable,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/users", ID: "createUser", Tag: "Users", Public: true,
+		Summary:     "사용자 생성 (회원가입)",
+		Description: "새로운 사용자를 기본 역할(user)로 생성합니다.",
+		Request:     []Body{{MediaType: "application/json", Value: dto.CreateUserRequest{}}},
+		Responses: []Response{
+			{Status: http.StatusCreated, Description: "사용자 생성 성공", Body: userBody{}, Headers: []string{"ETag"}},
+			badBody, conflict, tooLarge,
+			unavailable,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/users", ID: "listUsers", Tag: "Users", Permission: rbac.UsersList,
+		Summary:     "사용자 목록 조회",
+		Description: "사용자 목록을 한 페이지씩 조회합니다. page/per_page 또는 cursor로 페이지를 지정합니다.",
+		List:        query.Users,
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "사용자 목록 조회 성공", Body: userListBody{}, Headers: []string{"Link"}},
+			badList, unauthorized, forbidden,
+			unavailable,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/users/trash", ID: "listDeletedUsers", Tag: "Users", Permission: rbac.UsersTrash,
+		Summary:     "삭제된 사용자 조회 (휴지통)",
+		Description: "삭제된 사용자를 최근 삭제 순으로 조회합니다.",
+		List:        query.DeletedUsers,
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "휴지통 조회 성공", Body: userListBody{}, Headers: []string{"Link"}},
+			badList, unauthorized, forbidden,
+			unavailable,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/users/{id}/restore", ID: "restoreUser", Tag: "Users", Permission: rbac.UsersTrash,
+		Summary:     "삭제된 사용자 복구",
+		Description: "휴지통의 사용자를 복구합니다.",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "복구 성공", Body: userBody{}, Headers: []string{"ETag"}},
+			invalidID, unauthorized, forbidden,
+			fail(http.StatusNotFound, "휴지통에 없는 사용자"),
+			fail(http.StatusConflict, "같은 이메일/사용자명의 활성 사용자가 있음"),
+			unavailable,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/users/{id}", ID: "getUser", Tag: "Users", Permission: rbac.UsersRead,
+		Summary:     "특정 사용자 조회",
+		Description: "ID로 특정 사용자를 조회합니다.",
+		Params:      []Param{ifNoneMatch},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "사용자 조회 성공", Body: userBody{}, Headers: []string{"ETag"}},
+			{Status: http.StatusNotModified, Description: "변경 없음 (본문 없음)", Headers: []string{"ETag"}},
+			invalidID, unauthorized, forbidden, notFound,
+			unavailable,
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/api/users/{id}", ID: "replaceUser", Tag: "Users", Permission: rbac.UsersUpdate,
+		Summary:     "사용자 수정 (전체 교체)",
+		Description: "사용자의 이메일, 사용자명, 비밀번호를 모두 교체합니다. 본인 또는 admin만 수정할 수 있습니다.",
+		Params:      []Param{ifMatch},
+		Request:     []Body{{MediaType: "application/json", Value: dto.UpdateUserRequest{}}},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "사용자 수정 성공", Body: userBody{}, Headers: []string{"ETag"}},
+			badBody, unauthorized, forbidden, notFound, conflict, stale, missingMatch, tooLarge,
+			unavailable,
+		},
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/users/{id}", ID: "patchUser", Tag: "Users", Permission: rbac.UsersUpdate,
+		Summary:     "사용자 부분 수정",
+		Description: "JSON Merge Patch(RFC 7396) 또는 JSON Patch(RFC 6902)로 보낸 필드만 수정합니다.",
+		Params:      []Param{ifMatch},
+		Request: []Body{
+			{MediaType: patch.MergePatchType, Value: userPatch{}},
+			{MediaType: patch.JSONPatchType, Value: []jsonPatchOperation{}},
+		},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "사용자 수정 성공", Body: userBody{}, Headers: []string{"ETag"}},
+			badBody, unauthorized, forbidden, notFound, conflict, stale, missingMatch,
+			fail(http.StatusUnprocessableEntity, "패치를 적용할 수 없음 (알 수 없는 필드 등)"),
+			tooLarge,
+			{Status: http.StatusUnsupportedMediaType, Description: "지원하지 않는 Content-Type", MediaType: problem.MediaType, Body: problem.Problem{}, Headers: []string{"Accept-Patch"}},
+			unavailable,
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/users/{id}", ID: "deleteUser", Tag: "Users", Permission: rbac.UsersDelete,
+		Summary:     "사용자 삭제",
+		Description: "사용자를 휴지통으로 이동합니다. hard=true이면 영구 삭제합니다 (users:purge 권한 필요).",
+		Params:      []Param{ifMatch, hard},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "사용자 삭제 성공", Body: messageBody{}},
+			fail(http.StatusBadRequest, "잘못된 요청 (잘못된 ID 또는 hard 값)"),
+			unauthorized, forbidden, notFound, stale, missingMatch,
+			unavailable,
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/users/{id}/sessions", ID: "revokeSessions", Tag: "Auth", Permission: rbac.SessionsRevoke,
+		Summary:     "사용자 세션 폐기",
+		Description: "사용자의 모든 리프레시 토큰을 폐기합니다.",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "폐기 성공", Body: revokedSessionsBody{}},
+			invalidID, unauthorized, forbidden,
+			unavailable,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/roles", ID: "listRoles", Tag: "Roles", Permission: rbac.RolesRead,
+		Summary:     "역할 목록 조회",
+		Description: "역할과 각 역할의 권한 목록을 조회합니다.",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "조회 성공", Body: rolesBody{}},
+			unauthorized, forbidden,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/users/{id}/roles", ID: "getUserRoles", Tag: "Roles", Permission: rbac.RolesRead,
+		Summary:     "사용자 역할 조회",
+		Description: "사용자가 가진 역할을 조회합니다.",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "조회 성공", Body: userRolesBody{}},
+			invalidID, unauthorized, forbidden, notFound,
+			unavailable,
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/api/users/{id}/roles", ID: "setUserRoles", Tag: "Roles", Permission: rbac.RolesAssign,
+		Summary:     "사용자 역할 지정",
+		Description: "사용자의 역할을 교체합니다.",
+		Request:     []Body{{MediaType: "application/json", Value: dto.UserRolesRequest{}}},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "지정 성공", Body: userRolesBody{}},
+			fail(http.StatusBadRequest, "잘못된 요청 (알 수 없는 역할 등)"),
+			unauthorized, forbidden, notFound, tooLarge,
+			unavailable,
+		},
+	},
+}
+
+// listParams returns the page, sort and filter parameters of a list spec
+func listParams(spec *query.Spec) []parameter {
+	params := []parameter{
+		{Name: "page", In: "query", Description: "페이지 번호 (1부터)",
+			Schema: &Schema{Type: "integer", Minimum: float(1), Default: 1}},
+		{Name: "per_page", In: "query", Description: "페이지 크기",
+			Schema: &Schema{Type: "integer", Minimum: float(1), Maximum: float(query.MaxPerPage), Default: query.DefaultPerPage}},
+		{Name: "cursor", In: "query", Description: "이전 응답의 meta.next_cursor (page와 함께 사용 불가)",
+			Schema: &Schema{Type: "string"}},
+		{Name: "sort", In: "query",
+			Description: "정렬 필드 (쉼표 구분, -는 내림차순): " + strings.Join(sortedKeys(spec.Sortable), ", "),
+			Schema:      &Schema{Type: "string", Default: spec.DefaultSort}},
+	}
+
+	for _, name := range sortedKeys(spec.Filters) {
+		filter := spec.Filters[name]
+
+		var description string
+		switch filter.Op {
+		case query.OpEqual:
+			description = fmt.Sprintf("%s 일치 검색", filter.Column)
+		case query.OpContains:
+			description = fmt.Sprintf("%s 부분 일치 검색 (대소문자 무시)", filter.Column)
+		case query.OpAfter:
+			description = fmt.Sprintf("%s 하한", filter.Column)
+		case query.OpBefore:
+			description = fmt.Sprintf("%s 상한", filter.Column)
+		}
+
+		schema := &Schema{Type: "string"}
+		switch filter.Kind {
+		case query.KindInt:
+			schema = &Schema{Type: "integer"}
+		case query.KindTime:
+			description += " (RFC 3339 또는 YYYY-MM-DD)"
+		}
+		params = append(params, parameter{Name: name, In: "query", Description: description, Schema: schema})
+	}
+	return params
+}
@@ -0,0 +1,289 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"common/validation"
+)
+
+// Schema is a JSON Schema (draft 2020-12) as used by OpenAPI 3.1
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+// mode tells whether a type is read from requests or written to responses.
+// Request fields are required when their validate tag says so; response
+// fields are required unless they are omitempty.
+type mode int
+
+const (
+	request mode = iota
+	responseMode
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// generator turns Go types into schemas, collecting named structs as
+// components
+type generator struct {
+	schemas map[string]*Schema
+	// types remembers which type owns each component name
+	types map[string]reflect.Type
+}
+
+func newGenerator() *generator {
+	return &generator{schemas: map[string]*Schema{}, types: map[string]reflect.Type{}}
+}
+
+// schemaOf returns the schema of the type of v
+func (g *generator) schemaOf(v interface{}, m mode) (*Schema, error) {
+	return g.schemaOfType(reflect.TypeOf(v), m)
+}
+
+func (g *generator) schemaOfType(t reflect.Type, m mode) (*Schema, error) {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schemaOfType(t.Elem(), m)
+	case reflect.Struct:
+		return g.component(t, m)
+	case reflect.Slice, reflect.Array:
+		items, err := g.schemaOfType(t.Elem(), m)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := g.schemaOfType(t.Elem(), m)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: float(0)}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.Interface:
+		// Any JSON value
+		return &Schema{}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// component registers a struct under components/schemas and returns a
+// reference to it
+func (g *generator) component(t reflect.Type, m mode) (*Schema, error) {
+	name := componentName(t)
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+
+	if owner, ok := g.types[name]; ok {
+		if owner != t {
+			return nil, fmt.Errorf("types %s and %s share the schema name %s", owner, t, name)
+		}
+		return ref, nil
+	}
+	// Register before descending so recursive types terminate
+	g.types[name] = t
+	g.schemas[name] = &Schema{}
+
+	schema, err := g.structSchema(t, m)
+	if err != nil {
+		return nil, err
+	}
+	g.schemas[name] = schema
+	return ref, nil
+}
+
+// componentName names the component of a struct after its Go type,
+// capitalized so unexported types read like the others
+func componentName(t reflect.Type) string {
+	runes := []rune(t.Name())
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// structSchema builds the object schema of a struct from its json and
+// validate tags
+func (g *generator) structSchema(t reflect.Type, m mode) (*Schema, error) {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	if m == request {
+		// Request bodies with unknown members are rejected
+		schema.AdditionalProperties = false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitempty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		// Embedded structs without a json name are flattened
+		if field.Anonymous && name == "" {
+			embedded, err := g.structSchema(indirect(field.Type), m)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range embedded.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property, err := g.schemaOfType(field.Type, m)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", t.Name(), field.Name, err)
+		}
+
+		required, err := applyRules(property, field.Tag.Get("validate"))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", t.Name(), field.Name, err)
+		}
+		if m == responseMode {
+			required = !omitempty
+		}
+
+		schema.Properties[name] = property
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema, nil
+}
+
+// jsonName parses the json tag of a field
+func jsonName(field reflect.StructField) (name string, omitempty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return parts[0], omitempty, false
+}
+
+// applyRules adds the constraints of a validate tag (required, omitempty,
+// email, min, max, oneof, username, dive) to a schema and reports whether the
+// field is required. Rules after dive apply to the items of a slice. The
+// rules are enforced by the validation package.
+func applyRules(schema *Schema, tag string) (required bool, err error) {
+	target := schema
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "", "omitempty":
+		case "required":
+			if target == schema {
+				required = true
+			}
+		case "dive":
+			if target.Items == nil {
+				return false, fmt.Errorf("dive on a non-array field")
+			}
+			target = target.Items
+		case "email":
+			target.Format = "email"
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				return false, fmt.Errorf("rule %q: %v", rule, err)
+			}
+			setBound(target, name == "min", n)
+		case "oneof":
+			for _, v := range strings.Fields(arg) {
+				target.Enum = append(target.Enum, v)
+			}
+		case "username":
+			target.Pattern = validation.UsernamePattern
+		default:
+			// Rules without a schema equivalent are enforced by the server only
+		}
+	}
+	return required, nil
+}
+
+// setBound applies a min or max rule the way the validator interprets it:
+// length for strings, item count for arrays and value for numbers
+func setBound(schema *Schema, min bool, n int) {
+	switch schema.Type {
+	case "string":
+		if min {
+			schema.MinLength = &n
+		} else {
+			schema.MaxLength = &n
+		}
+	case "array":
+		if min {
+			schema.MinItems = &n
+		} else {
+			schema.MaxItems = &n
+		}
+	default:
+		if min {
+			schema.Minimum = float(n)
+		} else {
+			schema.Maximum = float(n)
+		}
+	}
+}
+
+// indirect returns the element type of a pointer type
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// float returns a pointer to n as a float64
+func float(n int) *float64 {
+	f := float64(n)
+	return &f
+}
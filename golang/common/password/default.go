@@ -0,0 +1,35 @@
+package password
+
+import "sync/atomic"
+
+var defaultHasher atomic.Pointer[Hasher]
+
+func init() {
+	defaultHasher.Store(NewHasher(DefaultParams))
+}
+
+// SetDefault replaces the Hasher used by the package-level functions
+func SetDefault(h *Hasher) {
+	defaultHasher.Store(h)
+}
+
+// Default returns the Hasher used by the package-level functions
+func Default() *Hasher {
+	return defaultHasher.Load()
+}
+
+// Hash hashes plain with the default Hasher
+func Hash(plain string) (string, error) {
+	return Default().Hash(plain)
+}
+
+// Verify checks plain against encoded with the default Hasher
+func Verify(plain, encoded string) (bool, error) {
+	return Default().Verify(plain, encoded)
+}
+
+// Check verifies plain with the default Hasher and returns a new hash when
+// the stored one is outdated
+func Check(plain, encoded string) (bool, string, error) {
+	return Default().Check(plain, encoded)
+}
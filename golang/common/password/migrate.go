@@ -0,0 +1,53 @@
+package password
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// migrateBatchSize is the number of users hashed per batch
+const migrateBatchSize = 100
+
+type passwordRow struct {
+	ID       uint
+	Password string
+}
+
+// MigratePlaintext hashes every password in the users table that is still
+// stored as plaintext, including soft-deleted rows. It is safe to run more
+// than once and returns the number of rows it converted.
+func MigratePlaintext(db *gorm.DB, h *Hasher) (int, error) {
+	var rows []passwordRow
+	converted := 0
+
+	result := db.Table("users").Select("id", "password").
+		FindInBatches(&rows, migrateBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, row := range rows {
+				if IsHash(row.Password) {
+					continue
+				}
+
+				hashed, err := h.Hash(row.Password)
+				if err != nil {
+					return err
+				}
+
+				// Update through the table so updated_at is left untouched, and
+				// only if the password was not changed since it was read
+				result := db.Table("users").Where("id = ? AND password = ?", row.ID, row.Password).
+					Update("password", hashed)
+				if result.Error != nil {
+					return fmt.Errorf("failed to update user %d: %v", row.ID, result.Error)
+				}
+				converted += int(result.RowsAffected)
+			}
+			return nil
+		})
+
+	if result.Error != nil {
+		return converted, result.Error
+	}
+
+	return converted, nil
+}
@@ -0,0 +1,207 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrMalformedHash is returned when a stored hash cannot be parsed
+	ErrMalformedHash = errors.New("password: malformed hash")
+
+	// ErrUnsupportedHash is returned when a stored value is not a known hash format
+	ErrUnsupportedHash = errors.New("password: unsupported hash format")
+)
+
+// Params holds the argon2id cost parameters
+type Params struct {
+	Memory      uint32 // memory in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams follows the OWASP recommendation for argon2id
+var DefaultParams = Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// ParamsFromEnv returns DefaultParams overridden by the
+// ARGON2_MEMORY, ARGON2_ITERATIONS and ARGON2_PARALLELISM environment variables
+func ParamsFromEnv() (Params, error) {
+	p := DefaultParams
+
+	if v := os.Getenv("ARGON2_MEMORY"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil || n < 8*1024 {
+			return p, fmt.Errorf("invalid ARGON2_MEMORY %q: must be at least 8192 KiB", v)
+		}
+		p.Memory = uint32(n)
+	}
+	if v := os.Getenv("ARGON2_ITERATIONS"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("invalid ARGON2_ITERATIONS %q", v)
+		}
+		p.Iterations = uint32(n)
+	}
+	if v := os.Getenv("ARGON2_PARALLELISM"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 8)
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("invalid ARGON2_PARALLELISM %q", v)
+		}
+		p.Parallelism = uint8(n)
+	}
+
+	return p, nil
+}
+
+// Hasher hashes new passwords with argon2id and verifies both argon2id
+// and bcrypt hashes
+type Hasher struct {
+	params Params
+}
+
+// NewHasher creates a Hasher using the given argon2id parameters
+func NewHasher(params Params) *Hasher {
+	return &Hasher{params: params}
+}
+
+// Hash returns the argon2id hash of plain in PHC string format
+func (h *Hasher) Hash(plain string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	key := argon2.IDKey([]byte(plain), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether plain matches the encoded argon2id or bcrypt hash
+func (h *Hasher) Verify(plain, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		p, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(plain), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+
+	case isBcrypt(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(plain))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("%w: %v", ErrMalformedHash, err)
+		}
+		return true, nil
+	}
+
+	return false, ErrUnsupportedHash
+}
+
+// NeedsRehash reports whether encoded was produced by another algorithm
+// or with parameters different from the Hasher's current ones
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	if !strings.HasPrefix(encoded, "$argon2id$") {
+		return true
+	}
+
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+
+	return p.Memory != h.params.Memory ||
+		p.Iterations != h.params.Iterations ||
+		p.Parallelism != h.params.Parallelism ||
+		uint32(len(salt)) != h.params.SaltLength ||
+		uint32(len(key)) != h.params.KeyLength
+}
+
+// Check verifies plain against encoded and, when the password matches but
+// the hash is outdated, returns a fresh hash the caller should persist.
+// This is the rehash-on-login path.
+func (h *Hasher) Check(plain, encoded string) (ok bool, rehashed string, err error) {
+	ok, err = h.Verify(plain, encoded)
+	if err != nil || !ok {
+		return false, "", err
+	}
+
+	if h.NeedsRehash(encoded) {
+		rehashed, err = h.Hash(plain)
+		if err != nil {
+			return true, "", err
+		}
+	}
+
+	return true, rehashed, nil
+}
+
+// IsHash reports whether s looks like a hash produced by a supported algorithm
+func IsHash(s string) bool {
+	return strings.HasPrefix(s, "$argon2id$") || isBcrypt(s)
+}
+
+func isBcrypt(s string) bool {
+	return len(s) == 60 && (strings.HasPrefix(s, "$2a$") ||
+		strings.HasPrefix(s, "$2b$") ||
+		strings.HasPrefix(s, "$2y$"))
+}
+
+// decodeArgon2id parses $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func decodeArgon2id(encoded string) (Params, []byte, []byte, error) {
+	var p Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, ErrMalformedHash
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("%w: argon2 version %d", ErrUnsupportedHash, version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, ErrMalformedHash
+	}
+
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,81 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Supported patch media types
+const (
+	MergePatchType = "application/merge-patch+json" // RFC 7396
+	JSONPatchType  = "application/json-patch+json"  // RFC 6902
+)
+
+// AcceptPatch is the value of the Accept-Patch header advertising the
+// supported media types
+const AcceptPatch = MergePatchType + ", " + JSONPatchType
+
+var (
+	// ErrUnsupportedMediaType is returned for a Content-Type that is not a patch format
+	ErrUnsupportedMediaType = errors.New("unsupported patch media type")
+	// ErrInvalidPatch is returned when the patch document itself is malformed
+	ErrInvalidPatch = errors.New("invalid patch document")
+	// ErrTestFailed is returned when a JSON Patch "test" operation does not match
+	ErrTestFailed = errors.New("patch test operation failed")
+	// ErrCannotApply is returned when a well-formed patch cannot be applied
+	ErrCannotApply = errors.New("patch cannot be applied")
+)
+
+// Apply applies body, interpreted according to contentType, to the JSON
+// document doc and returns the patched document. A plain application/json
+// body is treated as a merge patch.
+func Apply(contentType string, doc, body []byte) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedMediaType, contentType)
+	}
+
+	switch mediaType {
+	case MergePatchType, "application/json":
+		patched, err := jsonpatch.MergePatch(doc, body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return patched, nil
+
+	case JSONPatchType:
+		ops, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		patched, err := ops.Apply(doc)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return nil, fmt.Errorf("%w: %v", ErrTestFailed, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCannotApply, err)
+		}
+		return patched, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedMediaType, mediaType)
+	}
+}
+
+// Status returns the HTTP status code matching an error returned by Apply
+func Status(err error) int {
+	switch {
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrTestFailed):
+		return http.StatusConflict
+	case errors.Is(err, ErrCannotApply):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,91 @@
+package problem
+
+import "net/http"
+
+// Error codes. They are part of the API: never change the meaning of an
+// existing code, add a new one instead.
+const (
+	// CodeInvalidRequest is a malformed request without a more specific code
+	CodeInvalidRequest = "invalid_request"
+	// CodeInvalidBody is a request body that is not valid JSON
+	CodeInvalidBody = "invalid_body"
+	// CodeValidationFailed is a request body with invalid fields, listed in errors
+	CodeValidationFailed = "validation_failed"
+	// CodeInvalidQuery is an invalid page, sort or filter parameter
+	CodeInvalidQuery = "invalid_query"
+	// CodeInvalidID is a user ID in the path that is not a number
+	CodeInvalidID = "invalid_id"
+	// CodeInvalidPatch is a PATCH body that is not a valid patch document
+	CodeInvalidPatch = "invalid_patch"
+	// CodeUnknownRole is a role assignment naming a role that does not exist
+	CodeUnknownRole = "unknown_role"
+
+	// CodeUnauthorized is a request without a bearer token
+	CodeUnauthorized = "unauthorized"
+	// CodeInvalidToken is an invalid or expired access token
+	CodeInvalidToken = "invalid_token"
+	// CodeInvalidCredentials is a login with a wrong email/username or password
+	CodeInvalidCredentials = "invalid_credentials"
+	// CodeInvalidRefreshToken is an invalid, expired or revoked refresh token
+	CodeInvalidRefreshToken = "invalid_refresh_token"
+	// CodeRefreshTokenReused is a refresh token presented after it was rotated
+	CodeRefreshTokenReused = "refresh_token_reused"
+	// CodeForbidden is a request the principal lacks the permission for
+	CodeForbidden = "forbidden"
+
+	// CodeNotFound is a missing user or route
+	CodeNotFound = "not_found"
+	// CodeMethodNotAllowed is a route that does not support the method
+	CodeMethodNotAllowed = "method_not_allowed"
+	// CodeAlreadyExists is an email or username another user already has
+	CodeAlreadyExists = "already_exists"
+	// CodePatchTestFailed is a JSON Patch whose test operation failed
+	CodePatchTestFailed = "patch_test_failed"
+	// CodePreconditionFailed is an If-Match that no longer matches the resource
+	CodePreconditionFailed = "precondition_failed"
+	// CodeUnsupportedMediaType is a body in a format the endpoint does not accept
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	// CodeUnprocessable is a patch that cannot be applied to the resource
+	CodeUnprocessable = "unprocessable"
+	// CodePreconditionRequired is a write without the required If-Match
+	CodePreconditionRequired = "precondition_required"
+	// CodeBodyTooLarge is a request body over the size limit
+	CodeBodyTooLarge = "body_too_large"
+
+	// CodeInternal is an unexpected server error
+	CodeInternal = "internal"
+	// CodeUnavailable is a request failed fast while the database is failing;
+	// retry after the Retry-After header
+	CodeUnavailable = "unavailable"
+)
+
+// Field error codes
+const (
+	// FieldRequired is a missing or empty field
+	FieldRequired = "required"
+	// FieldInvalidEmail is an email field that is not a valid address
+	FieldInvalidEmail = "invalid_email"
+	// FieldTooShort is a value shorter than the minimum length
+	FieldTooShort = "too_short"
+	// FieldTooLong is a value longer than the maximum length
+	FieldTooLong = "too_long"
+	// FieldInvalidFormat is a value with characters the field does not allow
+	FieldInvalidFormat = "invalid_format"
+	// FieldInvalidValue is a value that is not one of the allowed values
+	FieldInvalidValue = "invalid_value"
+	// FieldWeakPassword is a password that does not meet the password policy
+	FieldWeakPassword = "weak_password"
+	// FieldUnknown is a member the request body does not declare
+	FieldUnknown = "unknown_field"
+	// FieldInvalidType is a value of the wrong JSON type
+	FieldInvalidType = "invalid_type"
+)
+
+// statusCodes are the codes of the error statuses routers produce on their own
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeInvalidRequest,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusRequestEntityTooLarge: CodeBodyTooLarge,
+	http.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+}
@@ -0,0 +1,98 @@
+// Package problem implements RFC 7807 problem details, the body of every
+// error response of the API.
+package problem
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// MediaType is the content type of problem responses
+const MediaType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Code is a stable,
+// machine-readable identifier of the error; clients should branch on it
+// rather than on Detail, which is meant for humans.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+
+	// Err is the cause of the problem. It is logged for server errors and
+	// never sent to the client.
+	Err error `json:"-"`
+
+	// RetryAfter is the number of seconds sent in a Retry-After header, or
+	// 0 for none
+	RetryAfter int `json:"-"`
+}
+
+// FieldError is a request field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// New returns a problem with a status, code and detail
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// FromStatus returns the problem of an error status a router produced on
+// its own, e.g. 404 for an unknown route
+func FromStatus(status int) *Problem {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = CodeInvalidRequest
+		if status >= http.StatusInternalServerError {
+			code = CodeInternal
+		}
+	}
+	return New(status, code, "")
+}
+
+// Internal returns the problem of an unexpected error. The response only
+// says that something went wrong; err is logged when the problem is sent.
+func Internal(err error) *Problem {
+	p := New(http.StatusInternalServerError, CodeInternal, "An unexpected error occurred")
+	p.Err = err
+	return p
+}
+
+// Unavailable returns the problem of a request rejected while a dependency
+// is failing. Clients should retry after retryAfter.
+func Unavailable(retryAfter time.Duration, err error) *Problem {
+	p := New(http.StatusServiceUnavailable, CodeUnavailable, "The service is temporarily unavailable")
+	p.Err = err
+	// Round up, so a client retrying on time does not arrive early
+	p.RetryAfter = int((retryAfter + time.Second - 1) / time.Second)
+	if p.RetryAfter < 1 {
+		p.RetryAfter = 1
+	}
+	return p
+}
+
+// ForRequest completes p for the request it answers and logs the cause of
+// server errors. Unavailable problems are not logged: the failing
+// dependency was logged once when it started failing.
+func (p *Problem) ForRequest(method, path, requestID string) *Problem {
+	p.Instance = path
+	p.RequestID = requestID
+	if p.Status >= http.StatusInternalServerError && p.Code != CodeUnavailable {
+		slog.Error("request failed", "method", method, "path", path, "request_id", requestID, "error", p.Err)
+	}
+	return p
+}
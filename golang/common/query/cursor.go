@@ -0,0 +1,96 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// cursorPayload is the decoded form of an opaque cursor. It records the sort
+// it was issued for so it cannot be replayed against a different order.
+type cursorPayload struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// encodeCursor builds the cursor pointing after a row with the given sort values
+func encodeCursor(opts *Options, values []interface{}) (string, error) {
+	payload := cursorPayload{Sort: opts.sortParam}
+	for _, v := range values {
+		switch v := v.(type) {
+		case time.Time:
+			payload.Values = append(payload.Values, v.UTC().Format(time.RFC3339Nano))
+		default:
+			payload.Values = append(payload.Values, fmt.Sprint(v))
+		}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor validates a cursor against the parsed sort and returns the
+// values of the last row of the previous page
+func decodeCursor(raw string, opts *Options) ([]interface{}, error) {
+	invalid := fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, invalid
+	}
+	if payload.Sort != opts.sortParam {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidQuery)
+	}
+	if len(payload.Values) != len(opts.Sort) {
+		return nil, invalid
+	}
+
+	values := make([]interface{}, len(opts.Sort))
+	for i, field := range opts.Sort {
+		v, err := parseValue(field.Kind, payload.Values[i])
+		if err != nil {
+			return nil, invalid
+		}
+		values[i] = v
+	}
+
+	return values, nil
+}
+
+// keysetCondition builds the WHERE clause selecting the rows that come after
+// the cursor, e.g. for "-created_at,id":
+//
+//	(created_at < ?) OR (created_at = ? AND id > ?)
+func keysetCondition(sort []SortField, values []interface{}) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+
+	for i, field := range sort {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, sort[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+
+		op := " > ?"
+		if field.Desc {
+			op = " < ?"
+		}
+		parts = append(parts, field.Column+op)
+		args = append(args, values[i])
+
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return strings.Join(clauses, " OR "), args
+}
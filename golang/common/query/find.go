@@ -0,0 +1,104 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Meta describes the page returned by Find
+type Meta struct {
+	Total      int64  `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	PerPage    int    `json:"per_page"`
+	TotalPages int    `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Find loads one page of rows into dest, which must be a pointer to a slice
+// of models. db may carry extra scopes such as Preload.
+func Find(db *gorm.DB, opts *Options, dest interface{}) (*Meta, error) {
+	filtered := applyConditions(db.Model(dest), opts.Conditions)
+
+	var total int64
+	if err := filtered.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count rows: %v", err)
+	}
+
+	tx := filtered.Session(&gorm.Session{})
+	if opts.IsCursor() {
+		where, args := keysetCondition(opts.Sort, opts.cursor)
+		tx = tx.Where(where, args...)
+	} else {
+		tx = tx.Offset((opts.Page - 1) * opts.PerPage)
+	}
+	for _, field := range opts.Sort {
+		order := field.Column
+		if field.Desc {
+			order += " DESC"
+		}
+		tx = tx.Order(order)
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	tx = tx.Limit(opts.PerPage + 1).Find(dest)
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to fetch rows: %v", tx.Error)
+	}
+
+	meta := &Meta{Total: total, PerPage: opts.PerPage}
+	if !opts.IsCursor() {
+		meta.Page = opts.Page
+		meta.TotalPages = int((total + int64(opts.PerPage) - 1) / int64(opts.PerPage))
+	}
+
+	rows := reflect.ValueOf(dest).Elem()
+	if rows.Len() > opts.PerPage {
+		rows.SetLen(opts.PerPage)
+
+		cursor, err := cursorAfter(tx, opts, rows.Index(opts.PerPage-1))
+		if err != nil {
+			return nil, err
+		}
+		meta.NextCursor = cursor
+	}
+
+	return meta, nil
+}
+
+// applyConditions adds the WHERE clauses of the parsed filters
+func applyConditions(db *gorm.DB, conditions []Condition) *gorm.DB {
+	for _, c := range conditions {
+		switch c.Op {
+		case OpContains:
+			db = db.Where("LOWER("+c.Column+") LIKE LOWER(?) ESCAPE '!'", "%"+escapeLike(fmt.Sprint(c.Value))+"%")
+		case OpAfter:
+			db = db.Where(c.Column+" > ?", c.Value)
+		case OpBefore:
+			db = db.Where(c.Column+" < ?", c.Value)
+		default:
+			db = db.Where(c.Column+" = ?", c.Value)
+		}
+	}
+	return db
+}
+
+// cursorAfter reads the sort values of row through the model schema
+func cursorAfter(tx *gorm.DB, opts *Options, row reflect.Value) (string, error) {
+	values := make([]interface{}, len(opts.Sort))
+	for i, sf := range opts.Sort {
+		field := tx.Statement.Schema.LookUpField(sf.Column)
+		if field == nil {
+			return "", fmt.Errorf("unknown sort column %q", sf.Column)
+		}
+		values[i], _ = field.ValueOf(tx.Statement.Context, row)
+	}
+	return encodeCursor(opts, values)
+}
+
+// escapeLike escapes the LIKE wildcards in s using "!" as the escape character
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
@@ -0,0 +1,49 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Links builds an RFC 8288 Link header value for the page described by meta.
+// path and values are those of the current request.
+func Links(path string, values url.Values, opts *Options, meta *Meta) string {
+	var links []string
+	add := func(rel string, set func(url.Values)) {
+		q := url.Values{}
+		for k, v := range values {
+			q[k] = v
+		}
+		q.Del("page")
+		q.Del("cursor")
+		set(q)
+		links = append(links, fmt.Sprintf(`<%s?%s>; rel="%s"`, path, q.Encode(), rel))
+	}
+	page := func(n int) func(url.Values) {
+		return func(q url.Values) { q.Set("page", strconv.Itoa(n)) }
+	}
+
+	if meta.NextCursor != "" {
+		if opts.IsCursor() {
+			add("next", func(q url.Values) { q.Set("cursor", meta.NextCursor) })
+		} else {
+			add("next", page(opts.Page+1))
+		}
+	}
+
+	if opts.IsCursor() {
+		add("first", func(url.Values) {})
+	} else {
+		if opts.Page > 1 {
+			add("prev", page(opts.Page-1))
+		}
+		add("first", page(1))
+		if meta.TotalPages > 0 {
+			add("last", page(meta.TotalPages))
+		}
+	}
+
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,174 @@
+package query_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"common/models"
+	"common/query"
+	"common/repository"
+)
+
+// usernames are stored in id order; sorted by name they come out shuffled
+var usernames = []string{"mallory", "alice", "trent", "bob", "eve", "carol", "dave"}
+
+// newUsers returns a repository holding a user per name in usernames
+func newUsers(t *testing.T) *repository.MemoryUserRepository {
+	t.Helper()
+
+	repo := repository.NewMemoryUserRepository()
+	for _, name := range usernames {
+		user := &models.User{Email: name + "@example.com", Username: name, Password: "hash"}
+		if err := repo.Create(context.Background(), user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+// list parses a raw query and lists the active users with it
+func list(t *testing.T, repo repository.UserRepository, raw string) ([]models.User, *query.Options, *query.Meta) {
+	t.Helper()
+
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := query.Users.Parse(values)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	users, meta, err := repo.List(context.Background(), repository.Active, opts)
+	if err != nil {
+		t.Fatalf("list %q: %v", raw, err)
+	}
+	return users, opts, meta
+}
+
+func names(users []models.User) []string {
+	out := make([]string, len(users))
+	for i, u := range users {
+		out[i] = u.Username
+	}
+	return out
+}
+
+func TestCursorWalksEveryRow(t *testing.T) {
+	repo := newUsers(t)
+
+	for _, sort := range []string{"id", "-id", "username", "-username", "-created_at,username"} {
+		t.Run(sort, func(t *testing.T) {
+			all, _, _ := list(t, repo, "per_page=100&sort="+url.QueryEscape(sort))
+
+			var walked []models.User
+			raw := "per_page=2&sort=" + url.QueryEscape(sort)
+			for pages := 0; ; pages++ {
+				if pages > len(usernames) {
+					t.Fatal("cursor did not reach the last page")
+				}
+				page, _, meta := list(t, repo, raw)
+				walked = append(walked, page...)
+				if meta.NextCursor == "" {
+					break
+				}
+				raw = "per_page=2&sort=" + url.QueryEscape(sort) + "&cursor=" + url.QueryEscape(meta.NextCursor)
+			}
+
+			if got, want := fmt.Sprint(names(walked)), fmt.Sprint(names(all)); got != want {
+				t.Errorf("walked %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestCursorRejected(t *testing.T) {
+	repo := newUsers(t)
+	_, _, meta := list(t, repo, "per_page=2&sort=username")
+	cursor := url.QueryEscape(meta.NextCursor)
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"different sort", "per_page=2&sort=id&cursor=" + cursor},
+		{"different direction", "per_page=2&sort=-username&cursor=" + cursor},
+		{"malformed", "per_page=2&sort=username&cursor=not-a-cursor"},
+		{"combined with page", "per_page=2&sort=username&page=2&cursor=" + cursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := query.Users.Parse(values); !errors.Is(err, query.ErrInvalidQuery) {
+				t.Errorf("got %v, want ErrInvalidQuery", err)
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	repo := newUsers(t)
+	_, _, first := list(t, repo, "per_page=3&sort=username")
+	next := url.QueryEscape(first.NextCursor)
+
+	// {next} stands for the next cursor of the listed page
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			"first page",
+			"per_page=3&sort=username",
+			`</api/users?page=2&per_page=3&sort=username>; rel="next", ` +
+				`</api/users?page=1&per_page=3&sort=username>; rel="first", ` +
+				`</api/users?page=3&per_page=3&sort=username>; rel="last"`,
+		},
+		{
+			"middle page",
+			"per_page=3&sort=username&page=2",
+			`</api/users?page=3&per_page=3&sort=username>; rel="next", ` +
+				`</api/users?page=1&per_page=3&sort=username>; rel="prev", ` +
+				`</api/users?page=1&per_page=3&sort=username>; rel="first", ` +
+				`</api/users?page=3&per_page=3&sort=username>; rel="last"`,
+		},
+		{
+			"last page",
+			"per_page=3&sort=username&page=3",
+			`</api/users?page=2&per_page=3&sort=username>; rel="prev", ` +
+				`</api/users?page=1&per_page=3&sort=username>; rel="first", ` +
+				`</api/users?page=3&per_page=3&sort=username>; rel="last"`,
+		},
+		{
+			"cursor",
+			"per_page=3&sort=username&cursor=" + next,
+			`</api/users?cursor={next}&per_page=3&sort=username>; rel="next", ` +
+				`</api/users?per_page=3&sort=username>; rel="first"`,
+		},
+		{
+			"empty",
+			"per_page=3&username=nobody",
+			`</api/users?page=1&per_page=3&username=nobody>; rel="first"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, opts, meta := list(t, repo, tt.raw)
+
+			want := strings.ReplaceAll(tt.want, "{next}", url.QueryEscape(meta.NextCursor))
+			if got := query.Links("/api/users", values, opts, meta); got != want {
+				t.Errorf("got  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
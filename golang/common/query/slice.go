@@ -0,0 +1,147 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Slice is the in-memory counterpart of Find: it filters, sorts and pages
+// rows held in memory. value returns the value of a column of a row.
+func Slice[T any](rows []T, opts *Options, value func(row T, column string) interface{}) ([]T, *Meta, error) {
+	var matched []T
+	for _, row := range rows {
+		if matches(row, opts.Conditions, value) {
+			matched = append(matched, row)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return compareRows(matched[i], matched[j], opts.Sort, value) < 0
+	})
+
+	meta := &Meta{Total: int64(len(matched)), PerPage: opts.PerPage}
+	start := 0
+	if opts.IsCursor() {
+		start = sort.Search(len(matched), func(i int) bool {
+			return compareToCursor(matched[i], opts, value) > 0
+		})
+	} else {
+		meta.Page = opts.Page
+		meta.TotalPages = (len(matched) + opts.PerPage - 1) / opts.PerPage
+		start = (opts.Page - 1) * opts.PerPage
+	}
+	if start > len(matched) {
+		start = len(matched)
+	}
+
+	page := matched[start:]
+	if len(page) > opts.PerPage {
+		page = page[:opts.PerPage]
+
+		last := page[len(page)-1]
+		values := make([]interface{}, len(opts.Sort))
+		for i, field := range opts.Sort {
+			values[i] = value(last, field.Column)
+		}
+		cursor, err := encodeCursor(opts, values)
+		if err != nil {
+			return nil, nil, err
+		}
+		meta.NextCursor = cursor
+	}
+
+	return page, meta, nil
+}
+
+// matches applies the filters the way applyConditions does in SQL
+func matches[T any](row T, conditions []Condition, value func(T, string) interface{}) bool {
+	for _, c := range conditions {
+		v := value(row, c.Column)
+		switch c.Op {
+		case OpContains:
+			if !strings.Contains(strings.ToLower(fmt.Sprint(v)), strings.ToLower(fmt.Sprint(c.Value))) {
+				return false
+			}
+		case OpAfter:
+			if compareValues(v, c.Value) <= 0 {
+				return false
+			}
+		case OpBefore:
+			if compareValues(v, c.Value) >= 0 {
+				return false
+			}
+		default:
+			if compareValues(v, c.Value) != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// compareRows orders two rows by the sort fields
+func compareRows[T any](a, b T, fields []SortField, value func(T, string) interface{}) int {
+	for _, field := range fields {
+		if c := compareValues(value(a, field.Column), value(b, field.Column)); c != 0 {
+			if field.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// compareToCursor orders a row relative to the row the cursor points at
+func compareToCursor[T any](row T, opts *Options, value func(T, string) interface{}) int {
+	for i, field := range opts.Sort {
+		if c := compareValues(value(row, field.Column), opts.cursor[i]); c != 0 {
+			if field.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues compares integers, strings (ignoring case) and times
+func compareValues(a, b interface{}) int {
+	if ta, ok := a.(time.Time); ok {
+		tb, _ := b.(time.Time)
+		switch {
+		case ta.Before(tb):
+			return -1
+		case ta.After(tb):
+			return 1
+		}
+		return 0
+	}
+
+	if na, ok := toInt(a); ok {
+		nb, _ := toInt(b)
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(strings.ToLower(fmt.Sprint(a)), strings.ToLower(fmt.Sprint(b)))
+}
+
+func toInt(v interface{}) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,213 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidQuery is wrapped by every error caused by bad query parameters
+var ErrInvalidQuery = errors.New("invalid query")
+
+const (
+	// DefaultPerPage is the page size used when per_page is not given
+	DefaultPerPage = 20
+	// MaxPerPage is the largest page size a client may request
+	MaxPerPage = 100
+)
+
+// Kind is the type of a sortable or filterable column
+type Kind int
+
+const (
+	KindInt Kind = iota
+	KindString
+	KindTime
+)
+
+// Field is a column exposed to clients under a public name
+type Field struct {
+	Column string
+	Kind   Kind
+}
+
+// Op is the comparison applied by a filter
+type Op int
+
+const (
+	OpEqual    Op = iota // column = value
+	OpContains           // column contains value, ignoring case
+	OpAfter              // column > value
+	OpBefore             // column < value
+)
+
+// Filter maps a query parameter to a condition on a column
+type Filter struct {
+	Field
+	Op Op
+}
+
+// Spec declares which fields of a resource can be sorted and filtered
+type Spec struct {
+	Sortable    map[string]Field
+	Filters     map[string]Filter
+	DefaultSort string
+	// Key is the unique column appended to every sort so the order is total
+	Key string
+}
+
+// SortField is one entry of the sort parameter
+type SortField struct {
+	Name string
+	Field
+	Desc bool
+}
+
+// Condition is a parsed filter ready to be applied
+type Condition struct {
+	Filter
+	Value interface{}
+}
+
+// Options are the parsed pagination, sorting and filtering parameters
+type Options struct {
+	Page       int
+	PerPage    int
+	Sort       []SortField
+	Conditions []Condition
+
+	sortParam string
+	cursor    []interface{}
+}
+
+// IsCursor reports whether the client asked for keyset pagination
+func (o *Options) IsCursor() bool {
+	return o.cursor != nil
+}
+
+// Parse validates the list parameters of a request:
+//
+//	page, per_page     offset pagination (1-based)
+//	cursor             opaque keyset cursor from a previous response
+//	sort               comma-separated fields, "-" prefix for descending
+//	<filter>           any filter declared in the spec
+func (s *Spec) Parse(values url.Values) (*Options, error) {
+	opts := &Options{Page: 1, PerPage: DefaultPerPage}
+
+	if v := values.Get("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxPerPage {
+			return nil, fmt.Errorf("%w: per_page must be between 1 and %d", ErrInvalidQuery, MaxPerPage)
+		}
+		opts.PerPage = n
+	}
+
+	if v := values.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%w: page must be a positive integer", ErrInvalidQuery)
+		}
+		opts.Page = n
+	}
+
+	sortParam := values.Get("sort")
+	if sortParam == "" {
+		sortParam = s.DefaultSort
+	}
+	fields, err := s.parseSort(sortParam)
+	if err != nil {
+		return nil, err
+	}
+	opts.Sort = fields
+	opts.sortParam = sortParam
+
+	if v := values.Get("cursor"); v != "" {
+		if values.Get("page") != "" {
+			return nil, fmt.Errorf("%w: page and cursor cannot be combined", ErrInvalidQuery)
+		}
+		cursor, err := decodeCursor(v, opts)
+		if err != nil {
+			return nil, err
+		}
+		opts.cursor = cursor
+	}
+
+	names := make([]string, 0, len(s.Filters))
+	for name := range s.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		filter := s.Filters[name]
+		v := values.Get(name)
+		if v == "" {
+			continue
+		}
+		value, err := parseValue(filter.Kind, v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidQuery, name, err)
+		}
+		opts.Conditions = append(opts.Conditions, Condition{Filter: filter, Value: value})
+	}
+
+	return opts, nil
+}
+
+// parseSort resolves "-created_at,username" against the sortable fields and
+// appends the key column as a tie-breaker
+func (s *Spec) parseSort(param string) ([]SortField, error) {
+	var fields []SortField
+	seen := make(map[string]bool)
+	hasKey := false
+
+	for _, part := range strings.Split(param, ",") {
+		part = strings.TrimSpace(part)
+		desc := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(part, "-")
+
+		field, ok := s.Sortable[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %q appears more than once in sort", ErrInvalidQuery, name)
+		}
+		seen[name] = true
+		hasKey = hasKey || field.Column == s.Key
+
+		fields = append(fields, SortField{Name: name, Field: field, Desc: desc})
+	}
+
+	if !hasKey {
+		fields = append(fields, SortField{Name: s.Key, Field: Field{Column: s.Key, Kind: KindInt}})
+	}
+
+	return fields, nil
+}
+
+// parseValue converts a raw parameter to the Go type of the column
+func parseValue(kind Kind, raw string) (interface{}, error) {
+	switch kind {
+	case KindInt:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", raw)
+		}
+		return n, nil
+	case KindTime:
+		if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse("2006-01-02", raw); err == nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("%q is not an RFC 3339 timestamp or YYYY-MM-DD date", raw)
+	default:
+		return raw, nil
+	}
+}
@@ -0,0 +1,41 @@
+package query
+
+// Users is the list spec of GET /api/users
+var Users = &Spec{
+	Sortable: map[string]Field{
+		"id":         {Column: "id", Kind: KindInt},
+		"email":      {Column: "email", Kind: KindString},
+		"username":   {Column: "username", Kind: KindString},
+		"created_at": {Column: "created_at", Kind: KindTime},
+		"updated_at": {Column: "updated_at", Kind: KindTime},
+	},
+	Filters: map[string]Filter{
+		"email":             {Field: Field{Column: "email", Kind: KindString}, Op: OpEqual},
+		"username":          {Field: Field{Column: "username", Kind: KindString}, Op: OpEqual},
+		"email_contains":    {Field: Field{Column: "email", Kind: KindString}, Op: OpContains},
+		"username_contains": {Field: Field{Column: "username", Kind: KindString}, Op: OpContains},
+		"created_after":     {Field: Field{Column: "created_at", Kind: KindTime}, Op: OpAfter},
+		"created_before":    {Field: Field{Column: "created_at", Kind: KindTime}, Op: OpBefore},
+	},
+	DefaultSort: "id",
+	Key:         "id",
+}
+
+// DeletedUsers is the list spec of GET /api/users/trash
+var DeletedUsers = &Spec{
+	Sortable: withField(Users.Sortable, "deleted_at", Field{Column: "deleted_at", Kind: KindInt}),
+	Filters:  Users.Filters,
+	// Most recently deleted first
+	DefaultSort: "-deleted_at",
+	Key:         "id",
+}
+
+// withField returns a copy of fields with one more entry
+func withField(fields map[string]Field, name string, field Field) map[string]Field {
+	copied := make(map[string]Field, len(fields)+1)
+	for k, v := range fields {
+		copied[k] = v
+	}
+	copied[name] = field
+	return copied
+}
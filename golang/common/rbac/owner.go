@@ -0,0 +1,13 @@
+package rbac
+
+import "strconv"
+
+// OwnerID parses the :id route parameter of a user resource. It returns 0
+// for a missing or malformed ID so only grants with the Any scope apply.
+func OwnerID(param string) uint {
+	id, err := strconv.ParseUint(param, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
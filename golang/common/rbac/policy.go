@@ -0,0 +1,141 @@
+package rbac
+
+import (
+	"common/auth"
+)
+
+// Built-in roles
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+	RoleUser    = "user"
+)
+
+// DefaultRole is assigned to every newly registered user
+const DefaultRole = RoleUser
+
+// Permission names an action on the users API
+type Permission string
+
+// Permissions checked by the route layer
+const (
+	UsersList      Permission = "users:list"
+	UsersRead      Permission = "users:read"
+	UsersUpdate    Permission = "users:update"
+	UsersDelete    Permission = "users:delete"
+	UsersTrash     Permission = "users:trash"
+	UsersPurge     Permission = "users:purge"
+	SessionsRevoke Permission = "sessions:revoke"
+	RolesRead      Permission = "roles:read"
+	RolesAssign    Permission = "roles:assign"
+)
+
+// Scope limits a grant to the principal's own record or allows it on any user
+type Scope int
+
+const (
+	// Own grants the permission only on the principal's own user record
+	Own Scope = iota
+	// Any grants the permission on every user
+	Any
+)
+
+// Grant gives a role a permission within a scope
+type Grant struct {
+	Permission Permission
+	Scope      Scope
+}
+
+// RoleDefinition describes a built-in role and what it may do
+type RoleDefinition struct {
+	Name        string
+	Description string
+	Grants      []Grant
+}
+
+// Policy is the single access policy shared by every framework variant
+var Policy = []RoleDefinition{
+	{
+		Name:        RoleAdmin,
+		Description: "Full access to all users, roles and sessions",
+		Grants: []Grant{
+			{UsersList, Any},
+			{UsersRead, Any},
+			{UsersUpdate, Any},
+			{UsersDelete, Any},
+			{UsersTrash, Any},
+			{UsersPurge, Any},
+			{SessionsRevoke, Any},
+			{RolesRead, Any},
+			{RolesAssign, Any},
+		},
+	},
+	{
+		Name:        RoleSupport,
+		Description: "Read access to all users for customer support",
+		Grants: []Grant{
+			{UsersList, Any},
+			{UsersRead, Any},
+			{UsersUpdate, Own},
+			{RolesRead, Any},
+		},
+	},
+	{
+		Name:        RoleUser,
+		Description: "Access to the user's own account",
+		Grants: []Grant{
+			{UsersRead, Own},
+			{UsersUpdate, Own},
+			{RolesRead, Own},
+		},
+	},
+}
+
+// Can reports whether the principal may perform perm on the user with
+// ownerID. Pass ownerID 0 for actions that do not target a single user.
+func Can(p *auth.Principal, perm Permission, ownerID uint) bool {
+	if p == nil {
+		return false
+	}
+
+	for _, role := range p.Roles {
+		def, ok := Lookup(role)
+		if !ok {
+			continue
+		}
+		for _, grant := range def.Grants {
+			if grant.Permission != perm {
+				continue
+			}
+			if grant.Scope == Any || (ownerID != 0 && ownerID == p.UserID) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Lookup returns the definition of a built-in role
+func Lookup(name string) (RoleDefinition, bool) {
+	for _, def := range Policy {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return RoleDefinition{}, false
+}
+
+// Permissions lists the permissions of a role, suffixed with ":own" when
+// limited to the user's own record
+func (d RoleDefinition) Permissions() []string {
+	perms := make([]string, 0, len(d.Grants))
+	for _, grant := range d.Grants {
+		name := string(grant.Permission)
+		if grant.Scope == Own {
+			name += ":own"
+		}
+		perms = append(perms, name)
+	}
+	return perms
+}
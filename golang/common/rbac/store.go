@@ -0,0 +1,88 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+
+	"common/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUnknownRole is returned when a role name is not part of the policy
+var ErrUnknownRole = errors.New("unknown role")
+
+// SeedRoles makes sure every role in the policy exists in the roles table
+func SeedRoles(db *gorm.DB) error {
+	for _, def := range Policy {
+		role := models.Role{Name: def.Name, Description: def.Description}
+		err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"description"}),
+		}).Create(&role).Error
+		if err != nil {
+			return fmt.Errorf("failed to seed role %q: %v", def.Name, err)
+		}
+	}
+	return nil
+}
+
+// FindRoles loads the roles with the given names. Every name must be part
+// of the policy.
+func FindRoles(db *gorm.DB, names []string) ([]models.Role, error) {
+	unique := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := Lookup(name); !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownRole, name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+
+	roles := []models.Role{}
+	if len(unique) == 0 {
+		return roles, nil
+	}
+
+	if err := db.Where("name IN ?", unique).Order("id ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	if len(roles) != len(unique) {
+		return nil, fmt.Errorf("%w: roles are not seeded", ErrUnknownRole)
+	}
+
+	return roles, nil
+}
+
+// DefaultRoles returns the roles assigned to a newly registered user
+func DefaultRoles(db *gorm.DB) ([]models.Role, error) {
+	return FindRoles(db, []string{DefaultRole})
+}
+
+// SetUserRoles replaces the roles of a user
+func SetUserRoles(db *gorm.DB, user *models.User, names []string) error {
+	roles, err := FindRoles(db, names)
+	if err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Association("Roles").Replace(roles); err != nil {
+			return fmt.Errorf("failed to assign roles: %v", err)
+		}
+
+		// Roles are part of the user's representation, so they change its ETag
+		return tx.Model(user).UpdateColumn("version", gorm.Expr("version + 1")).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	user.Roles = roles
+	user.Version++
+	return nil
+}
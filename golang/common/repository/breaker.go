@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"common/auth"
+	"common/breaker"
+	"common/dto"
+	"common/models"
+	"common/query"
+	"common/rbac"
+)
+
+// BreakerUserRepository guards a UserRepository with a circuit breaker.
+// While the database is failing its methods return a *breaker.OpenError at
+// once instead of waiting for a connection of the pool.
+type BreakerUserRepository struct {
+	next    UserRepository
+	breaker *breaker.Breaker
+}
+
+// NewDatabaseBreaker returns the breaker named "database". Share one
+// between the repository and the session manager, so every database call
+// fails fast once the database is failing.
+func NewDatabaseBreaker(cfg breaker.Config) *breaker.Breaker {
+	return breaker.New("database", cfg, failing)
+}
+
+// NewBreakerUserRepository wraps next with b
+func NewBreakerUserRepository(next UserRepository, b *breaker.Breaker) *BreakerUserRepository {
+	return &BreakerUserRepository{next: next, breaker: b}
+}
+
+// failing reports whether err means the database is failing rather than
+// the request, e.g. a missing user, a wrong password or a client that went
+// away
+func failing(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrNotFound),
+		errors.Is(err, ErrDuplicate),
+		errors.Is(err, ErrStale),
+		errors.Is(err, rbac.ErrUnknownRole),
+		errors.Is(err, auth.ErrInvalidCredentials),
+		errors.Is(err, auth.ErrInvalidRefreshToken),
+		errors.Is(err, auth.ErrRefreshTokenReused),
+		errors.Is(err, context.Canceled):
+		return false
+	default:
+		return true
+	}
+}
+
+// Create stores a new user with the default roles
+func (r *BreakerUserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.breaker.Do(func() error {
+		return r.next.Create(ctx, user)
+	})
+}
+
+// GetByID returns a user of the scope
+func (r *BreakerUserRepository) GetByID(ctx context.Context, id uint, scope Scope) (*models.User, error) {
+	var user *models.User
+	err := r.breaker.Do(func() (err error) {
+		user, err = r.next.GetByID(ctx, id, scope)
+		return err
+	})
+	return user, err
+}
+
+// List returns one page of the users of the scope
+func (r *BreakerUserRepository) List(ctx context.Context, scope Scope, opts *query.Options) ([]models.User, *query.Meta, error) {
+	var users []models.User
+	var meta *query.Meta
+	err := r.breaker.Do(func() (err error) {
+		users, meta, err = r.next.List(ctx, scope, opts)
+		return err
+	})
+	return users, meta, err
+}
+
+// Update writes changes and bumps the version
+func (r *BreakerUserRepository) Update(ctx context.Context, user *models.User, changes dto.UserChanges) error {
+	return r.breaker.Do(func() error {
+		return r.next.Update(ctx, user, changes)
+	})
+}
+
+// Delete moves an active user to the trash
+func (r *BreakerUserRepository) Delete(ctx context.Context, user *models.User) error {
+	return r.breaker.Do(func() error {
+		return r.next.Delete(ctx, user)
+	})
+}
+
+// HardDelete permanently removes a user
+func (r *BreakerUserRepository) HardDelete(ctx context.Context, id uint) error {
+	return r.breaker.Do(func() error {
+		return r.next.HardDelete(ctx, id)
+	})
+}
+
+// Restore moves a trashed user back out of the trash
+func (r *BreakerUserRepository) Restore(ctx context.Context, user *models.User) error {
+	return r.breaker.Do(func() error {
+		return r.next.Restore(ctx, user)
+	})
+}
+
+// SetRoles replaces the roles of a user
+func (r *BreakerUserRepository) SetRoles(ctx context.Context, user *models.User, names []string) error {
+	return r.breaker.Do(func() error {
+		return r.next.SetRoles(ctx, user, names)
+	})
+}
@@ -0,0 +1,93 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"common/auth"
+	"common/breaker"
+	"common/models"
+	"common/rbac"
+	"common/repository"
+	"common/service"
+)
+
+// failingRepository answers GetByID with err, or a user when err is nil
+type failingRepository struct {
+	repository.UserRepository
+	err   error
+	calls int
+}
+
+func (r *failingRepository) GetByID(ctx context.Context, id uint, scope repository.Scope) (*models.User, error) {
+	r.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &models.User{ID: id}, nil
+}
+
+func TestBreakerUserRepository(t *testing.T) {
+	const failures = 2
+	cfg := breaker.Config{Failures: failures, Cooldown: 20 * time.Millisecond}
+
+	tests := []struct {
+		name string
+		err  error
+		open bool
+	}{
+		{"not found", repository.ErrNotFound, false},
+		{"duplicate", repository.ErrDuplicate, false},
+		{"stale", repository.ErrStale, false},
+		{"unknown role", fmt.Errorf("failed to set roles: %w", rbac.ErrUnknownRole), false},
+		{"canceled", fmt.Errorf("query failed: %w", context.Canceled), false},
+		{"invalid credentials", auth.ErrInvalidCredentials, false},
+		{"invalid refresh token", auth.ErrInvalidRefreshToken, false},
+		{"database down", errors.New("dial tcp: connection refused"), true},
+		{"deadline exceeded", context.DeadlineExceeded, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &failingRepository{err: tt.err}
+			repo := repository.NewBreakerUserRepository(fake, repository.NewDatabaseBreaker(cfg))
+			ctx := context.Background()
+
+			for i := 0; i < failures; i++ {
+				if _, err := repo.GetByID(ctx, 1, repository.Active); !errors.Is(err, tt.err) {
+					t.Fatalf("call %d: %v, want %v", i, err, tt.err)
+				}
+			}
+
+			_, err := repo.GetByID(ctx, 1, repository.Active)
+			var open *breaker.OpenError
+			if got := errors.As(err, &open); got != tt.open {
+				t.Fatalf("open error %v, want %v", err, tt.open)
+			}
+			if !tt.open {
+				return
+			}
+			if fake.calls != failures {
+				t.Errorf("%d calls reached the repository, want %d", fake.calls, failures)
+			}
+
+			p := service.Problem(err)
+			if p.Status != http.StatusServiceUnavailable || p.RetryAfter < 1 {
+				t.Errorf("problem %d with Retry-After %d, want 503 with at least 1", p.Status, p.RetryAfter)
+			}
+
+			// The trial call after the cooldown closes the breaker again
+			time.Sleep(cfg.Cooldown)
+			fake.err = nil
+			if _, err := repo.GetByID(ctx, 1, repository.Active); err != nil {
+				t.Fatalf("trial call: %v", err)
+			}
+			if _, err := repo.GetByID(ctx, 1, repository.Active); err != nil {
+				t.Errorf("call after trial: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"common/dto"
+	"common/models"
+	"common/query"
+	"common/rbac"
+	"common/trash"
+
+	"gorm.io/gorm"
+)
+
+// GormUserRepository stores users through GORM. The database must be opened
+// with TranslateError so unique violations surface as gorm.ErrDuplicatedKey.
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewGormUserRepository creates a GormUserRepository
+func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+// Create stores a new user with the default roles
+func (r *GormUserRepository) Create(ctx context.Context, user *models.User) error {
+	db := r.db.WithContext(ctx)
+
+	roles, err := rbac.DefaultRoles(db)
+	if err != nil {
+		return err
+	}
+	user.Roles = roles
+
+	return translate(db.Create(user).Error)
+}
+
+// GetByID returns a user of the scope with its roles
+func (r *GormUserRepository) GetByID(ctx context.Context, id uint, scope Scope) (*models.User, error) {
+	var user models.User
+	if err := r.scoped(ctx, scope).Preload("Roles").First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+// List returns one page of the users of the scope
+func (r *GormUserRepository) List(ctx context.Context, scope Scope, opts *query.Options) ([]models.User, *query.Meta, error) {
+	var users []models.User
+	meta, err := query.Find(r.scoped(ctx, scope).Preload("Roles"), opts, &users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, meta, nil
+}
+
+// Update writes changes and bumps the version if it still matches
+func (r *GormUserRepository) Update(ctx context.Context, user *models.User, changes dto.UserChanges) error {
+	if changes.IsEmpty() {
+		return nil
+	}
+
+	columns := map[string]interface{}{
+		"version": gorm.Expr("version + 1"),
+	}
+	if changes.Email != nil {
+		columns["email"] = *changes.Email
+	}
+	if changes.Username != nil {
+		columns["username"] = *changes.Username
+	}
+	if changes.Password != nil {
+		columns["password"] = *changes.Password
+	}
+
+	db := r.db.WithContext(ctx)
+	result := db.Model(user).Where("version = ?", user.Version).Updates(columns)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrStale
+	}
+
+	return translate(db.Preload("Roles").First(user, user.ID).Error)
+}
+
+// Delete moves a user to the trash if its version still matches
+func (r *GormUserRepository) Delete(ctx context.Context, user *models.User) error {
+	result := r.db.WithContext(ctx).Where("version = ?", user.Version).Delete(user)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// HardDelete permanently removes a user
+func (r *GormUserRepository) HardDelete(ctx context.Context, id uint) error {
+	return trash.HardDelete(r.db.WithContext(ctx), id)
+}
+
+// Restore moves a trashed user back out of the trash
+func (r *GormUserRepository) Restore(ctx context.Context, user *models.User) error {
+	db := r.db.WithContext(ctx)
+
+	err := trash.Restore(db, user)
+	switch {
+	case errors.Is(err, trash.ErrConflict):
+		return ErrDuplicate
+	case errors.Is(err, trash.ErrNotDeleted):
+		return ErrNotFound
+	case err != nil:
+		return err
+	}
+
+	return translate(db.Preload("Roles").First(user, user.ID).Error)
+}
+
+// SetRoles replaces the roles of a user
+func (r *GormUserRepository) SetRoles(ctx context.Context, user *models.User, names []string) error {
+	return rbac.SetUserRoles(r.db.WithContext(ctx), user, names)
+}
+
+// scoped limits queries to the users of a scope
+func (r *GormUserRepository) scoped(ctx context.Context, scope Scope) *gorm.DB {
+	db := r.db.WithContext(ctx)
+	switch scope {
+	case Trashed:
+		return trash.Deleted(db)
+	case AnyState:
+		return db.Unscoped()
+	default:
+		return db
+	}
+}
+
+// translate maps GORM errors to the repository errors
+func translate(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicate
+	default:
+		return err
+	}
+}
@@ -0,0 +1,40 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"common/internal/testdb"
+	"common/models"
+	"common/repository"
+)
+
+func TestGormDeleteTwiceWithinOneSecond(t *testing.T) {
+	db := testdb.Open(t)
+	repo := repository.NewGormUserRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	deletes := []struct {
+		name string
+		at   time.Time
+		want error
+	}{
+		{"first", base, nil},
+		{"same second", base.Add(time.Millisecond), nil},
+		{"same millisecond", base.Add(time.Millisecond), repository.ErrDuplicate},
+	}
+	for _, d := range deletes {
+		db.Config.NowFunc = func() time.Time { return d.at }
+
+		user := &models.User{Email: "alice@example.com", Username: "alice", Password: "hash"}
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("%s: create: %v", d.name, err)
+		}
+		if err := repo.Delete(ctx, user); !errors.Is(err, d.want) {
+			t.Errorf("%s: delete: %v, want %v", d.name, err, d.want)
+		}
+	}
+}
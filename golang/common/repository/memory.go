@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"common/dto"
+	"common/models"
+	"common/query"
+	"common/rbac"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+// MemoryUserRepository keeps users in memory, e.g. for handler tests.
+// It is safe for concurrent use and enforces the same uniqueness, version
+// and trash rules as the database.
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[uint]*models.User
+	nextID uint
+	now    func() time.Time
+}
+
+// NewMemoryUserRepository creates an empty MemoryUserRepository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:  make(map[uint]*models.User),
+		nextID: 1,
+		now:    time.Now,
+	}
+}
+
+// Create stores a new user with the default roles
+func (r *MemoryUserRepository) Create(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.taken(user.Email, user.Username, 0) {
+		return ErrDuplicate
+	}
+
+	roles, err := policyRoles([]string{rbac.DefaultRole})
+	if err != nil {
+		return err
+	}
+
+	now := r.timestamp()
+	user.ID = r.nextID
+	user.Version = 1
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	user.DeletedAt = 0
+	user.Roles = roles
+	r.nextID++
+
+	r.users[user.ID] = clone(user)
+	return nil
+}
+
+// GetByID returns a user of the scope
+func (r *MemoryUserRepository) GetByID(_ context.Context, id uint, scope Scope) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || !inScope(user, scope) {
+		return nil, ErrNotFound
+	}
+	return clone(user), nil
+}
+
+// List returns one page of the users of the scope
+func (r *MemoryUserRepository) List(_ context.Context, scope Scope, opts *query.Options) ([]models.User, *query.Meta, error) {
+	r.mu.RLock()
+	rows := make([]models.User, 0, len(r.users))
+	for _, user := range r.users {
+		if inScope(user, scope) {
+			rows = append(rows, *clone(user))
+		}
+	}
+	r.mu.RUnlock()
+
+	return query.Slice(rows, opts, userColumn)
+}
+
+// Update writes changes and bumps the version if it still matches
+func (r *MemoryUserRepository) Update(_ context.Context, user *models.User, changes dto.UserChanges) error {
+	if changes.IsEmpty() {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || stored.IsDeleted() {
+		return ErrNotFound
+	}
+	if stored.Version != user.Version {
+		return ErrStale
+	}
+
+	email, username := stored.Email, stored.Username
+	if changes.Email != nil {
+		email = *changes.Email
+	}
+	if changes.Username != nil {
+		username = *changes.Username
+	}
+	if r.taken(email, username, stored.ID) {
+		return ErrDuplicate
+	}
+
+	stored.Email = email
+	stored.Username = username
+	if changes.Password != nil {
+		stored.Password = *changes.Password
+	}
+	stored.Version++
+	stored.UpdatedAt = r.timestamp()
+
+	*user = *clone(stored)
+	return nil
+}
+
+// Delete moves a user to the trash if its version still matches
+func (r *MemoryUserRepository) Delete(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || stored.IsDeleted() {
+		return ErrNotFound
+	}
+	if stored.Version != user.Version {
+		return ErrStale
+	}
+
+	stored.DeletedAt = soft_delete.DeletedAt(r.now().UnixMilli())
+	*user = *clone(stored)
+	return nil
+}
+
+// HardDelete permanently removes a user
+func (r *MemoryUserRepository) HardDelete(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, id)
+	return nil
+}
+
+// Restore moves a trashed user back out of the trash
+func (r *MemoryUserRepository) Restore(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || !stored.IsDeleted() {
+		return ErrNotFound
+	}
+	if r.taken(stored.Email, stored.Username, stored.ID) {
+		return ErrDuplicate
+	}
+
+	stored.DeletedAt = 0
+	stored.Version++
+	stored.UpdatedAt = r.timestamp()
+
+	*user = *clone(stored)
+	return nil
+}
+
+// SetRoles replaces the roles of a user
+func (r *MemoryUserRepository) SetRoles(_ context.Context, user *models.User, names []string) error {
+	roles, err := policyRoles(names)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || stored.IsDeleted() {
+		return ErrNotFound
+	}
+
+	stored.Roles = roles
+	stored.Version++
+	stored.UpdatedAt = r.timestamp()
+
+	*user = *clone(stored)
+	return nil
+}
+
+// taken reports whether another active user already has the email or
+// username, ignoring case like the unique indexes
+func (r *MemoryUserRepository) taken(email, username string, except uint) bool {
+	for id, user := range r.users {
+		if id == except || user.IsDeleted() {
+			continue
+		}
+		if strings.EqualFold(user.Email, email) || strings.EqualFold(user.Username, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// timestamp returns the current time without the monotonic clock reading,
+// as it would come back from the database
+func (r *MemoryUserRepository) timestamp() time.Time {
+	return r.now().Round(0)
+}
+
+// policyRoles builds the roles with the given names, numbered in policy order
+func policyRoles(names []string) ([]models.Role, error) {
+	seen := make(map[string]bool, len(names))
+	roles := []models.Role{}
+
+	for i, def := range rbac.Policy {
+		for _, name := range names {
+			if name == def.Name && !seen[name] {
+				seen[name] = true
+				roles = append(roles, models.Role{ID: uint(i + 1), Name: def.Name, Description: def.Description})
+			}
+		}
+	}
+
+	for _, name := range names {
+		if !seen[name] {
+			return nil, fmt.Errorf("%w %q", rbac.ErrUnknownRole, name)
+		}
+	}
+	return roles, nil
+}
+
+func inScope(user *models.User, scope Scope) bool {
+	switch scope {
+	case Trashed:
+		return user.IsDeleted()
+	case AnyState:
+		return true
+	default:
+		return !user.IsDeleted()
+	}
+}
+
+// clone copies a user so callers never share the stored value
+func clone(user *models.User) *models.User {
+	c := *user
+	c.Roles = append([]models.Role(nil), user.Roles...)
+	return &c
+}
+
+// userColumn returns the value of a sortable or filterable column
+func userColumn(user models.User, column string) interface{} {
+	switch column {
+	case "id":
+		return int64(user.ID)
+	case "email":
+		return user.Email
+	case "username":
+		return user.Username
+	case "created_at":
+		return user.CreatedAt
+	case "updated_at":
+		return user.UpdatedAt
+	case "deleted_at":
+		return int64(user.DeletedAt)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"common/dto"
+	"common/models"
+	"common/query"
+)
+
+var (
+	// ErrNotFound is returned when no user with the id exists in the scope
+	ErrNotFound = errors.New("user not found")
+
+	// ErrDuplicate is returned when an active user already has the email or username
+	ErrDuplicate = errors.New("email or username already exists")
+
+	// ErrStale is returned when the user was changed after it was read, i.e.
+	// its version no longer matches
+	ErrStale = errors.New("user was modified concurrently")
+)
+
+// Scope selects users by their trash state
+type Scope int
+
+const (
+	// Active users are those not in the trash
+	Active Scope = iota
+	// Trashed users are soft-deleted
+	Trashed
+	// AnyState includes active and trashed users
+	AnyState
+)
+
+// UserRepository loads and stores users. Users are returned with their
+// roles loaded. Methods taking a user update it in place on success.
+type UserRepository interface {
+	// Create stores a new user with the default roles
+	Create(ctx context.Context, user *models.User) error
+
+	// GetByID returns a user of the scope or ErrNotFound
+	GetByID(ctx context.Context, id uint, scope Scope) (*models.User, error)
+
+	// List returns one page of the users of the scope
+	List(ctx context.Context, scope Scope, opts *query.Options) ([]models.User, *query.Meta, error)
+
+	// Update writes changes, whose password must already be hashed, and
+	// bumps the version. It fails with ErrStale if user.Version is outdated.
+	Update(ctx context.Context, user *models.User, changes dto.UserChanges) error
+
+	// Delete moves an active user to the trash. It fails with ErrStale if
+	// user.Version is outdated.
+	Delete(ctx context.Context, user *models.User) error
+
+	// HardDelete permanently removes a user, active or trashed, together
+	// with its role assignments and refresh tokens
+	HardDelete(ctx context.Context, id uint) error
+
+	// Restore moves a trashed user back out of the trash. It fails with
+	// ErrDuplicate if an active user has taken its email or username.
+	Restore(ctx context.Context, user *models.User) error
+
+	// SetRoles replaces the roles of a user and bumps its version.
+	// Unknown role names fail with rbac.ErrUnknownRole.
+	SetRoles(ctx context.Context, user *models.User, names []string) error
+}
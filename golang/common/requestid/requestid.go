@@ -0,0 +1,58 @@
+// Package requestid assigns every request an ID that is echoed in the
+// X-Request-ID response header and in problem responses, so a client report
+// can be matched with the server logs.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header is the request and response header carrying the request ID
+const Header = "X-Request-ID"
+
+// maxLength is the longest request ID accepted from a client
+const maxLength = 128
+
+type contextKey struct{}
+
+// New returns a random request ID
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// The ID only correlates logs; an unreadable random source must
+		// not fail the request
+		return "unavailable"
+	}
+	return hex.EncodeToString(b)
+}
+
+// FromHeader returns the request ID sent by the client, or a new one when
+// the header is missing or is not a plausible ID. Accepting the client's ID
+// lets a proxy or caller trace a request across services.
+func FromHeader(header string) string {
+	if header == "" || len(header) > maxLength {
+		return New()
+	}
+	for _, c := range header {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return New()
+		}
+	}
+	return header
+}
+
+// WithContext returns a copy of ctx carrying the request ID
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// From returns the request ID stored in ctx, or "" if there is none
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
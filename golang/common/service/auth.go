@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+
+	"common/auth"
+	"common/dto"
+	"common/models"
+)
+
+// Login authenticates a user by email or username and starts a new session
+func (s *UserService) Login(ctx context.Context, req dto.LoginRequest) (*auth.TokenPair, *models.User, error) {
+	if err := req.Validate(); err != nil {
+		return nil, nil, invalid(err)
+	}
+
+	user, err := s.sessions.Authenticate(ctx, req.Login(), req.Password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair, err := s.sessions.Login(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, user, nil
+}
+
+// Refresh rotates a refresh token and issues a new token pair
+func (s *UserService) Refresh(ctx context.Context, req dto.RefreshRequest) (*auth.TokenPair, *models.User, error) {
+	if err := req.Validate(); err != nil {
+		return nil, nil, invalid(err)
+	}
+	return s.sessions.Refresh(ctx, req.RefreshToken)
+}
+
+// Logout revokes the session the refresh token belongs to
+func (s *UserService) Logout(ctx context.Context, req dto.RefreshRequest) error {
+	if err := req.Validate(); err != nil {
+		return invalid(err)
+	}
+	return s.sessions.Logout(ctx, req.RefreshToken)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"common/auth"
+	"common/breaker"
+	"common/etag"
+	"common/patch"
+	"common/problem"
+	"common/validation"
+)
+
+// Error is a failure of a use case together with the HTTP status, error
+// code and message the adapters respond with
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status for an error returned by the service
+func Status(err error) int {
+	return Problem(err).Status
+}
+
+// Problem maps an error returned by the service, the auth package or the
+// validation package to the problem the adapters respond with. Validation
+// errors list the rejected fields; an open database breaker is a 503 with
+// Retry-After. Errors the service does not know are internal: their text
+// may come from the database driver, so it is logged instead of sent.
+func Problem(err error) *problem.Problem {
+	var e *Error
+	if errors.As(err, &e) {
+		p := problem.New(e.Status, e.Code, e.Message)
+		p.Err = e.Err
+		var invalid *validation.Error
+		if errors.As(err, &invalid) {
+			p.Errors = invalid.Fields
+		}
+		return p
+	}
+
+	var invalid *validation.Error
+	var open *breaker.OpenError
+	switch {
+	case errors.As(err, &open):
+		return problem.Unavailable(open.RetryAfter, err)
+	case errors.As(err, &invalid):
+		p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, err.Error())
+		p.Errors = invalid.Fields
+		return p
+	case errors.Is(err, validation.ErrInvalidBody):
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "Request body must be a valid JSON object")
+	case errors.Is(err, validation.ErrBodyTooLarge):
+		detail := fmt.Sprintf("Request body must not exceed %d bytes", validation.MaxBodySize())
+		return problem.New(http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge, detail)
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		return problem.New(http.StatusUnauthorized, problem.CodeInvalidCredentials, err.Error())
+	case errors.Is(err, auth.ErrRefreshTokenReused):
+		return problem.New(http.StatusUnauthorized, problem.CodeRefreshTokenReused, err.Error())
+	case errors.Is(err, auth.ErrInvalidRefreshToken):
+		return problem.New(http.StatusUnauthorized, problem.CodeInvalidRefreshToken, err.Error())
+	case errors.Is(err, auth.ErrInvalidToken):
+		return problem.New(http.StatusUnauthorized, problem.CodeInvalidToken, err.Error())
+	default:
+		return problem.Internal(err)
+	}
+}
+
+// fail wraps err with the status, code and message to respond with
+func fail(status int, code, message string, err error) error {
+	return &Error{Status: status, Code: code, Message: message, Err: err}
+}
+
+// wrap wraps err keeping its own message
+func wrap(status int, code string, err error) error {
+	return &Error{Status: status, Code: code, Message: err.Error(), Err: err}
+}
+
+// invalid reports a request that failed validation. The fields of a
+// validation.Error are listed in the problem.
+func invalid(err error) error {
+	return wrap(http.StatusBadRequest, problem.CodeValidationFailed, err)
+}
+
+// patchError maps the errors of dto.PatchUser to responses
+func patchError(err error) error {
+	var fields *validation.Error
+	if errors.As(err, &fields) {
+		return invalid(err)
+	}
+	status := patch.Status(err)
+	return wrap(status, patchCodes[status], err)
+}
+
+// patchCodes are the error codes of the statuses patch.Status returns
+var patchCodes = map[int]string{
+	http.StatusBadRequest:           problem.CodeInvalidPatch,
+	http.StatusConflict:             problem.CodePatchTestFailed,
+	http.StatusUnsupportedMediaType: problem.CodeUnsupportedMediaType,
+	http.StatusUnprocessableEntity:  problem.CodeUnprocessable,
+}
+
+// preconditionError maps the errors of an If-Match check to responses
+func preconditionError(err error) error {
+	if errors.Is(err, etag.ErrPreconditionRequired) {
+		return wrap(etag.Status(err), problem.CodePreconditionRequired, err)
+	}
+	return wrap(etag.Status(err), problem.CodePreconditionFailed, err)
+}
+
+var (
+	// ErrInvalidID is returned when the user ID in the path is not a number
+	ErrInvalidID = errors.New("invalid user ID")
+
+	// ErrForbidden is returned when the principal lacks a permission the use
+	// case checks itself
+	ErrForbidden = errors.New("permission denied")
+)
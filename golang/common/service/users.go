@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"common/auth"
+	"common/dto"
+	"common/etag"
+	"common/models"
+	"common/password"
+	"common/problem"
+	"common/query"
+	"common/rbac"
+	"common/repository"
+)
+
+// UserService implements the user use cases shared by every framework.
+// Adapters decode the request, call one method and write the result, or
+// respond with the problem Problem(err) maps the error to.
+type UserService struct {
+	users    repository.UserRepository
+	sessions *auth.SessionManager
+}
+
+// NewUserService creates a UserService
+func NewUserService(users repository.UserRepository, sessions *auth.SessionManager) *UserService {
+	return &UserService{users: users, sessions: sessions}
+}
+
+// UserPage is one page of a user list
+type UserPage struct {
+	Users []models.User
+	Meta  *query.Meta
+	// Link is the value of the Link header with the neighbouring pages
+	Link string
+}
+
+// Create registers a new user with the default role
+func (s *UserService) Create(ctx context.Context, req dto.CreateUserRequest) (*models.User, error) {
+	if err := req.Validate(); err != nil {
+		return nil, invalid(err)
+	}
+
+	user := req.ToModel()
+
+	// Store only the password hash
+	hashed, err := password.Hash(user.Password)
+	if err != nil {
+		return nil, fail(http.StatusInternalServerError, problem.CodeInternal, "Failed to hash password", err)
+	}
+	user.Password = hashed
+
+	if err := s.users.Create(ctx, &user); err != nil {
+		return nil, storeError(err)
+	}
+	return &user, nil
+}
+
+// List returns one page of the users of a scope. path and rawQuery are the
+// request path and undecoded query, used to parse the options and build the
+// links. The query is parsed here so every adapter rejects a malformed one
+// alike.
+func (s *UserService) List(ctx context.Context, scope repository.Scope, spec *query.Spec, path, rawQuery string) (*UserPage, error) {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, wrap(http.StatusBadRequest, problem.CodeInvalidQuery, fmt.Errorf("%w: %v", query.ErrInvalidQuery, err))
+	}
+	opts, err := spec.Parse(values)
+	if err != nil {
+		return nil, wrap(http.StatusBadRequest, problem.CodeInvalidQuery, err)
+	}
+
+	users, meta, err := s.users.List(ctx, scope, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserPage{
+		Users: users,
+		Meta:  meta,
+		Link:  query.Links(path, values, opts, meta),
+	}, nil
+}
+
+// Get returns an active user
+func (s *UserService) Get(ctx context.Context, rawID string) (*models.User, error) {
+	id, err := parseID(rawID)
+	if err != nil {
+		return nil, err
+	}
+	return s.find(ctx, id, repository.Active)
+}
+
+// Replace overwrites the email, username and password of a user
+func (s *UserService) Replace(ctx context.Context, rawID, ifMatch string, req dto.UpdateUserRequest) (*models.User, error) {
+	user, err := s.Get(ctx, rawID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIfMatch(ifMatch, user); err != nil {
+		return nil, err
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, invalid(err)
+	}
+
+	return user, s.save(ctx, user, req.Changes())
+}
+
+// Patch applies a JSON Merge Patch or JSON Patch body to a user
+func (s *UserService) Patch(ctx context.Context, rawID, ifMatch, contentType string, body []byte) (*models.User, error) {
+	user, err := s.Get(ctx, rawID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIfMatch(ifMatch, user); err != nil {
+		return nil, err
+	}
+
+	changes, err := dto.PatchUser(user, contentType, body)
+	if err != nil {
+		return nil, patchError(err)
+	}
+
+	return user, s.save(ctx, user, changes)
+}
+
+// Delete moves a user to the trash, or removes it permanently when hard is
+// set. A hard delete needs its own permission and also reaches users in the
+// trash.
+func (s *UserService) Delete(ctx context.Context, rawID, ifMatch string, hard bool) error {
+	id, err := parseID(rawID)
+	if err != nil {
+		return err
+	}
+
+	scope := repository.Active
+	if hard {
+		principal, _ := auth.PrincipalFrom(ctx)
+		if !rbac.Can(principal, rbac.UsersPurge, id) {
+			return fail(http.StatusForbidden, problem.CodeForbidden, "You do not have permission to perform this action", ErrForbidden)
+		}
+		scope = repository.AnyState
+	}
+
+	user, err := s.find(ctx, id, scope)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(ifMatch, user); err != nil {
+		return err
+	}
+
+	if hard {
+		return s.users.HardDelete(ctx, user.ID)
+	}
+	if err := s.users.Delete(ctx, user); err != nil {
+		return storeError(err)
+	}
+	return nil
+}
+
+// Restore moves a soft-deleted user out of the trash
+func (s *UserService) Restore(ctx context.Context, rawID string) (*models.User, error) {
+	id, err := parseID(rawID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, id, repository.Trashed)
+	if err != nil {
+		return nil, notFound(err, "Deleted user not found")
+	}
+
+	if err := s.users.Restore(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fail(http.StatusNotFound, problem.CodeNotFound, "Deleted user not found", err)
+		}
+		return nil, storeError(err)
+	}
+	return user, nil
+}
+
+// SetRoles replaces the roles held by a user
+func (s *UserService) SetRoles(ctx context.Context, rawID string, req dto.UserRolesRequest) (*models.User, error) {
+	user, err := s.Get(ctx, rawID)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, invalid(err)
+	}
+
+	if err := s.users.SetRoles(ctx, user, req.Roles); err != nil {
+		if errors.Is(err, rbac.ErrUnknownRole) {
+			return nil, wrap(http.StatusBadRequest, problem.CodeUnknownRole, err)
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// RevokeSessions revokes every refresh token of a user and returns how many
+// were revoked
+func (s *UserService) RevokeSessions(ctx context.Context, rawID string) (int64, error) {
+	id, err := parseID(rawID)
+	if err != nil {
+		return 0, err
+	}
+	return s.sessions.RevokeUser(ctx, id)
+}
+
+// save writes changes to user. A new password ends every existing session
+// of the user.
+func (s *UserService) save(ctx context.Context, user *models.User, changes dto.UserChanges) error {
+	hashed, passwordChanged, err := changes.Hash(user)
+	if err != nil {
+		return err
+	}
+
+	if err := s.users.Update(ctx, user, hashed); err != nil {
+		return storeError(err)
+	}
+
+	if passwordChanged {
+		if _, err := s.sessions.RevokeUser(ctx, user.ID); err != nil {
+			slog.ErrorContext(ctx, "Failed to revoke sessions", "user_id", user.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// find returns a user of the scope or a 404 error
+func (s *UserService) find(ctx context.Context, id uint, scope repository.Scope) (*models.User, error) {
+	user, err := s.users.GetByID(ctx, id, scope)
+	if err != nil {
+		return nil, notFound(err, "User not found")
+	}
+	return user, nil
+}
+
+// storeError maps the errors of a repository write to responses
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrDuplicate):
+		return wrap(http.StatusConflict, problem.CodeAlreadyExists, err)
+	// Another request changed the user after it was read
+	case errors.Is(err, repository.ErrStale), errors.Is(err, repository.ErrNotFound):
+		return wrap(http.StatusPreconditionFailed, problem.CodePreconditionFailed, etag.ErrPreconditionFailed)
+	default:
+		return err
+	}
+}
+
+// notFound reports a missing user with message, keeping other errors as they are
+func notFound(err error, message string) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return fail(http.StatusNotFound, problem.CodeNotFound, message, err)
+	}
+	return err
+}
+
+// checkIfMatch compares the If-Match header with the current ETag of user
+func checkIfMatch(header string, user *models.User) error {
+	if err := etag.CheckIfMatch(header, etag.For(user.ID, user.Version)); err != nil {
+		return preconditionError(err)
+	}
+	return nil
+}
+
+// parseID parses the user ID of the request path
+func parseID(raw string) (uint, error) {
+	id, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		return 0, fail(http.StatusBadRequest, problem.CodeInvalidID, "Invalid user ID", ErrInvalidID)
+	}
+	return uint(id), nil
+}
+
+// ParseHard parses the hard query parameter of a delete. It defaults to
+// false when the parameter is missing.
+func ParseHard(raw string) (bool, error) {
+	if raw == "" {
+		return false, nil
+	}
+	hard, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, wrap(http.StatusBadRequest, problem.CodeInvalidQuery, fmt.Errorf("%w: hard must be true or false", query.ErrInvalidQuery))
+	}
+	return hard, nil
+}
@@ -0,0 +1,117 @@
+package settings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFiles are looked up in the working directory when CONFIG_FILE is
+// not set
+var defaultFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+// findFile returns the first default config file that exists, or ""
+func findFile() string {
+	for _, name := range defaultFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// readFile reads a YAML or TOML config file into settings named by their
+// environment variables. Nested keys are joined with underscores, so
+// db.password_file and DB_PASSWORD_FILE name the same setting.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	tree := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file %s: use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flatten("", tree, values); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	var unknown []string
+	for key := range values {
+		if !known(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("invalid config file %s: unknown setting(s) %s", path, strings.Join(unknown, ", "))
+	}
+	return values, nil
+}
+
+// flatten adds the scalar values of tree to values
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) error {
+	for name, value := range tree {
+		key := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(key, v, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("%s: lists are not supported", key)
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// passthrough lists the settings a config file may contain besides those of
+// Config: they are read by a framework or library, or by a command
+var passthrough = []string{"GIN_MODE", "MIGRATIONS_DIR"}
+
+// known reports whether key names a setting
+func known(key string) bool {
+	if strings.HasPrefix(key, "OTEL_") {
+		return true
+	}
+	for _, s := range secrets {
+		if key == s+"_FILE" {
+			return true
+		}
+	}
+	for _, s := range passthrough {
+		if key == s {
+			return true
+		}
+	}
+	for _, e := range (Config{}).entries() {
+		if key == e.key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package settings
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Masked replaces the value of a secret when the configuration is printed
+const Masked = "********"
+
+// entry is one printed setting
+type entry struct {
+	key   string
+	value string
+}
+
+// entries returns every setting of c by its environment variable
+func (c Config) entries() []entry {
+	return []entry{
+		{"PORT", strconv.Itoa(c.Port)},
+
+		{"DB_DRIVER", c.Database.Driver},
+		{"DB_HOST", c.Database.Host},
+		{"DB_PORT", c.Database.Port},
+		{"DB_USER", c.Database.User},
+		{"DB_PASSWORD", c.Database.Password},
+		{"DB_NAME", c.Database.Name},
+		{"DB_SSLMODE", c.Database.SSLMode},
+		{"DB_MAX_OPEN_CONNS", strconv.Itoa(c.Database.MaxOpenConns)},
+		{"DB_MAX_IDLE_CONNS", strconv.Itoa(c.Database.MaxIdleConns)},
+		{"DB_CONN_MAX_LIFETIME", duration(c.Database.ConnMaxLifetime)},
+		{"DB_CONNECT_TIMEOUT", duration(c.Database.ConnectTimeout)},
+		{"DB_BREAKER_FAILURES", strconv.Itoa(c.Breaker.Failures)},
+		{"DB_BREAKER_COOLDOWN", duration(c.Breaker.Cooldown)},
+		{"MIGRATE_ON_START", strconv.FormatBool(c.MigrateOnStart)},
+
+		{"JWT_ALGORITHM", c.Auth.Algorithm},
+		{"JWT_SECRET", string(c.Auth.Secret)},
+		{"JWT_PRIVATE_KEY_FILE", c.Auth.KeyFile},
+		{"JWT_ISSUER", c.Auth.Issuer},
+		{"JWT_ACCESS_TTL", duration(c.Auth.AccessTTL)},
+		{"JWT_REFRESH_TTL", duration(c.Auth.RefreshTTL)},
+		{"ARGON2_MEMORY", strconv.FormatUint(uint64(c.Password.Memory), 10)},
+		{"ARGON2_ITERATIONS", strconv.FormatUint(uint64(c.Password.Iterations), 10)},
+		{"ARGON2_PARALLELISM", strconv.FormatUint(uint64(c.Password.Parallelism), 10)},
+
+		{"REQUIRE_IF_MATCH", strconv.FormatBool(c.RequireIfMatch)},
+		{"MAX_BODY_SIZE", strconv.FormatInt(c.MaxBodySize, 10)},
+
+		{"TRASH_RETENTION", duration(c.Trash.Retention)},
+		{"TRASH_PURGE_INTERVAL", duration(c.Trash.Interval)},
+		{"SHUTDOWN_DELAY", duration(c.Shutdown.Delay)},
+		{"SHUTDOWN_TIMEOUT", duration(c.Shutdown.Timeout)},
+		{"READY_TIMEOUT", duration(c.Health.Timeout)},
+		{"LOG_LEVEL", strings.ToLower(c.Logging.Level.String())},
+		{"LOG_SLOW_QUERY", duration(c.Logging.SlowQuery)},
+		{"OTEL_TRACES_EXPORTER", c.Tracing.Exporter},
+		{"TRACES_FILE", c.Tracing.File},
+	}
+}
+
+// Print writes every setting as KEY=value, the format of a .env file, with
+// secrets masked. Settings not left at their default are followed by where
+// they came from.
+func Print(w io.Writer, c Config) error {
+	for _, e := range c.entries() {
+		value := e.value
+		if isSecret(e.key) && value != "" {
+			value = Masked
+		}
+
+		line := e.key + "=" + value
+		if source, ok := c.sources[e.key]; ok {
+			line += " # " + source
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isSecret(key string) bool {
+	for _, s := range secrets {
+		if key == s {
+			return true
+		}
+	}
+	return false
+}
+
+// duration formats d the way it is written in a setting, e.g. 15m or 720h
+func duration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
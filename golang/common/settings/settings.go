@@ -0,0 +1,192 @@
+// Package settings loads the configuration of a server into one typed
+// Config. Every setting is named by its environment variable and is taken
+// from, in increasing precedence, the package default, a YAML or TOML config
+// file, a .env file and the process environment. Secrets can be read from
+// files instead, e.g. DB_PASSWORD_FILE=/run/secrets/db_password.
+package settings
+
+import (
+	"common/auth"
+	"common/breaker"
+	"common/database"
+	"common/etag"
+	"common/health"
+	"common/lifecycle"
+	"common/logging"
+	"common/migrations"
+	"common/password"
+	"common/tracing"
+	"common/trash"
+	"common/validation"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// DefaultPort is the HTTP port when PORT is not set
+const DefaultPort = 3001
+
+// Config holds every setting of a server
+type Config struct {
+	// Port is the HTTP port
+	Port int
+
+	Database       database.Config
+	MigrateOnStart bool
+	// Breaker guards the user repository against a failing database
+	Breaker breaker.Config
+
+	Auth     auth.Config
+	Password password.Params
+
+	RequireIfMatch bool
+	MaxBodySize    int64
+
+	Trash    trash.Config
+	Shutdown lifecycle.Config
+	Health   health.Config
+	Logging  logging.Config
+	Tracing  tracing.Config
+
+	// sources names where each setting not left at its default came from
+	sources map[string]string
+}
+
+// Sources
+const (
+	sourceEnv    = "environment"
+	sourceDotEnv = ".env"
+)
+
+// secrets lists the settings that may be read from the file named by their
+// *_FILE variable and are masked when printed
+var secrets = []string{"DB_PASSWORD", "JWT_SECRET"}
+
+// Load reads the configuration and validates it, reporting every invalid
+// setting at once. Values of the config file and the .env file are exported
+// to the environment for the libraries that read it themselves, e.g. the
+// OTEL_EXPORTER_OTLP_* variables of the OTLP exporter.
+//
+// The config file is CONFIG_FILE, or config.yaml, config.yml or config.toml
+// in the working directory if one exists.
+func Load() (Config, error) {
+	sources := map[string]string{}
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		sources[key] = sourceEnv
+	}
+
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("failed to read .env: %v", err)
+	}
+	export(dotenv, sourceDotEnv, sources)
+
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		path = findFile()
+	}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+		export(values, path, sources)
+	}
+
+	if err := readSecrets(sources); err != nil {
+		return Config{}, err
+	}
+
+	cfg, err := fromEnv()
+	cfg.sources = sources
+	return cfg, err
+}
+
+// export sets the values that are not set yet, so sources loaded earlier
+// take precedence
+func export(values map[string]string, source string, sources map[string]string) {
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		os.Setenv(key, value)
+		sources[key] = source
+	}
+}
+
+// readSecrets replaces every *_FILE variable of a secret with the content of
+// the file it names
+func readSecrets(sources map[string]string) error {
+	for _, key := range secrets {
+		path := os.Getenv(key + "_FILE")
+		if path == "" {
+			continue
+		}
+		if os.Getenv(key) != "" {
+			return fmt.Errorf("%s and %s_FILE are both set; use one of them", key, key)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s_FILE: %v", key, err)
+		}
+		os.Setenv(key, strings.TrimRight(string(data), "\r\n"))
+		sources[key] = key + "_FILE"
+	}
+	return nil
+}
+
+// fromEnv builds the Config from the environment, collecting the errors of
+// every package
+func fromEnv() (Config, error) {
+	var cfg Config
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	cfg.Port = DefaultPort
+	if v := os.Getenv("PORT"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil || port < 1 || port > 65535 {
+			check(fmt.Errorf("invalid PORT %q", v))
+		}
+		cfg.Port = port
+	}
+
+	var err error
+	cfg.Database, err = database.ConfigFromEnv()
+	check(err)
+	cfg.MigrateOnStart, err = migrations.OnStartFromEnv()
+	check(err)
+	cfg.Breaker, err = breaker.ConfigFromEnv()
+	check(err)
+	cfg.Auth, err = auth.ConfigFromEnv()
+	check(err)
+	cfg.Password, err = password.ParamsFromEnv()
+	check(err)
+	cfg.RequireIfMatch, err = etag.RequireIfMatchFromEnv()
+	check(err)
+	cfg.MaxBodySize, err = validation.MaxBodySizeFromEnv()
+	check(err)
+	cfg.Trash, err = trash.ConfigFromEnv()
+	check(err)
+	cfg.Shutdown, err = lifecycle.ConfigFromEnv()
+	check(err)
+	cfg.Health, err = health.ConfigFromEnv()
+	check(err)
+	cfg.Logging, err = logging.ConfigFromEnv()
+	check(err)
+	cfg.Tracing, err = tracing.ConfigFromEnv()
+	check(err)
+
+	return cfg, errors.Join(errs...)
+}
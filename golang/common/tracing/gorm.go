@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// GormPlugin adds a client span for every query, a child of the span in
+// the statement's context, with the statement, the rows it affected and its
+// error. Statements are recorded with placeholders, never with values.
+type GormPlugin struct{}
+
+// Name implements gorm.Plugin
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize implements gorm.Plugin
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", startQuery),
+		cb.Create().After("gorm:create").Register("tracing:after_create", endQuery),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", startQuery),
+		cb.Query().After("gorm:query").Register("tracing:after_query", endQuery),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", startQuery),
+		cb.Update().After("gorm:update").Register("tracing:after_update", endQuery),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", startQuery),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", endQuery),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", startQuery),
+		cb.Row().After("gorm:row").Register("tracing:after_row", endQuery),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", startQuery),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", endQuery),
+	)
+}
+
+// querySpanKey stores the running query span in the statement
+const querySpanKey = "tracing:query"
+
+// querySpan is a running query span and the context it was started in
+type querySpan struct {
+	parent context.Context
+	span   trace.Span
+}
+
+func startQuery(db *gorm.DB) {
+	parent := db.Statement.Context
+	ctx, span := otel.Tracer(instrumentation).Start(parent, "gorm", trace.WithSpanKind(trace.SpanKindClient))
+	db.Statement.Context = ctx
+	db.InstanceSet(querySpanKey, querySpan{parent: parent, span: span})
+}
+
+func endQuery(db *gorm.DB) {
+	v, ok := db.InstanceGet(querySpanKey)
+	if !ok {
+		return
+	}
+	q := v.(querySpan)
+	// A statement may run again, e.g. a chain reused for a second Find
+	db.Statement.Context = q.parent
+
+	if q.span.IsRecording() {
+		statement := db.Statement.SQL.String()
+		operation, _, _ := strings.Cut(strings.TrimSpace(statement), " ")
+		operation = strings.ToUpper(operation)
+
+		name := operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		q.span.SetName(name)
+		q.span.SetAttributes(
+			dbSystem(db.Dialector.Name()),
+			semconv.DBStatement(statement),
+			semconv.DBOperation(operation),
+			semconv.DBSQLTable(db.Statement.Table),
+			attribute.Int64("db.rows_affected", db.RowsAffected),
+		)
+		// A missing row is an answer, not a failure
+		if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			q.span.RecordError(err)
+			q.span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	q.span.End()
+}
+
+// dbSystem maps a GORM dialect to the semantic convention's db.system
+func dbSystem(dialect string) attribute.KeyValue {
+	switch dialect {
+	case "mysql":
+		return semconv.DBSystemMySQL
+	case "postgres":
+		return semconv.DBSystemPostgreSQL
+	case "sqlite":
+		return semconv.DBSystemSqlite
+	}
+	return semconv.DBSystemOtherSQL
+}
@@ -0,0 +1,133 @@
+// Package tracing sets up OpenTelemetry tracing. Each framework starts a
+// server span per request, continuing the trace of a W3C traceparent
+// header, and the GORM plugin adds a child span for every query, so a slow
+// request shows whether the time went to the handler or the database.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// Exporters
+const (
+	ExporterNone    = "none"
+	ExporterOTLP    = "otlp"
+	ExporterConsole = "console"
+)
+
+// instrumentation names the tracer of this package's spans
+const instrumentation = "common/tracing"
+
+// Config selects where spans are exported
+type Config struct {
+	// Exporter is none, otlp or console
+	Exporter string
+	// File receives the spans of the console exporter instead of stdout
+	File string
+}
+
+// DefaultConfig records no spans
+var DefaultConfig = Config{Exporter: ExporterNone}
+
+// ConfigFromEnv reads OTEL_TRACES_EXPORTER and TRACES_FILE, falling back to
+// DefaultConfig. The OTLP exporter reads its own OTEL_EXPORTER_OTLP_*
+// variables, e.g. OTEL_EXPORTER_OTLP_ENDPOINT.
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig
+
+	if v := os.Getenv("OTEL_TRACES_EXPORTER"); v != "" {
+		switch v {
+		case ExporterNone, ExporterOTLP, ExporterConsole:
+			cfg.Exporter = v
+		default:
+			return cfg, fmt.Errorf("invalid OTEL_TRACES_EXPORTER %q: must be none, otlp or console", v)
+		}
+	}
+
+	cfg.File = os.Getenv("TRACES_FILE")
+	if cfg.File != "" && cfg.Exporter != ExporterConsole {
+		return cfg, fmt.Errorf("TRACES_FILE requires OTEL_TRACES_EXPORTER=console")
+	}
+
+	return cfg, nil
+}
+
+// Init installs the global tracer provider and the W3C trace context
+// propagator. service names the spans' resource unless OTEL_SERVICE_NAME is
+// set. The returned function flushes the remaining spans and stops the
+// provider; call it last when the server stops.
+func Init(cfg Config, service string) (func(), error) {
+	// Requests continue the trace of their traceparent header
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if cfg.Exporter == ExporterNone {
+		return func() {}, nil
+	}
+
+	var closer io.Closer
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(context.Background())
+	case ExporterConsole:
+		var w io.Writer = os.Stdout
+		if cfg.File != "" {
+			f, ferr := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if ferr != nil {
+				return nil, fmt.Errorf("tracing: %w", ferr)
+			}
+			w, closer = f, f
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName(service)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+	if env, err := resource.New(context.Background(), resource.WithFromEnv()); err == nil {
+		res, _ = resource.Merge(res, env)
+	}
+
+	// The sampler follows OTEL_TRACES_SAMPLER and defaults to sampling every
+	// trace without a sampled parent
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	log.Printf("Tracing enabled (%s exporter)", cfg.Exporter)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			slog.Error("Failed to flush traces", "exporter", cfg.Exporter, "error", err)
+		}
+		if closer != nil {
+			closer.Close()
+		}
+	}, nil
+}
@@ -0,0 +1,79 @@
+package trash
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Config controls the scheduled purge of the trash
+type Config struct {
+	// Retention is how long soft-deleted users are kept; 0 disables the purge
+	Retention time.Duration
+	// Interval is how often the purge runs
+	Interval time.Duration
+}
+
+// DefaultConfig keeps deleted users for 30 days and purges hourly
+var DefaultConfig = Config{
+	Retention: 30 * 24 * time.Hour,
+	Interval:  time.Hour,
+}
+
+// ConfigFromEnv reads TRASH_RETENTION and TRASH_PURGE_INTERVAL, falling back
+// to DefaultConfig
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig
+
+	if v := os.Getenv("TRASH_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return cfg, fmt.Errorf("invalid TRASH_RETENTION %q", v)
+		}
+		cfg.Retention = d
+	}
+
+	if v := os.Getenv("TRASH_PURGE_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid TRASH_PURGE_INTERVAL %q", v)
+		}
+		cfg.Interval = d
+	}
+
+	return cfg, nil
+}
+
+// StartPurger runs Purge every cfg.Interval in the background until the
+// returned stop function is called. It does nothing when cfg.Retention is 0.
+func StartPurger(db *gorm.DB, cfg Config) (stop func()) {
+	if cfg.Retention == 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if n, err := Purge(db, cfg.Retention); err != nil {
+				slog.Error("Failed to purge deleted users", "retention", cfg.Retention.String(), "error", err)
+			} else if n > 0 {
+				log.Printf("Purged %d deleted user(s)", n)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,152 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"common/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// purgeBatchSize is the number of users permanently deleted per batch
+const purgeBatchSize = 100
+
+var (
+	// ErrNotDeleted is returned when restoring a user that is not in the trash
+	ErrNotDeleted = errors.New("user is not deleted")
+	// ErrConflict is returned when an active user already has the email or
+	// username of the user being restored
+	ErrConflict = errors.New("another user already has this email or username")
+)
+
+// Deleted scopes a query to soft-deleted users
+func Deleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Where("users.deleted_at <> 0")
+}
+
+// Restore moves a soft-deleted user back out of the trash
+func Restore(db *gorm.DB, user *models.User) error {
+	if !user.IsDeleted() {
+		return ErrNotDeleted
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		err := tx.Model(&models.User{}).
+			Where("LOWER(email) = LOWER(?) OR LOWER(username) = LOWER(?)", user.Email, user.Username).
+			Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrConflict
+		}
+
+		result := tx.Unscoped().Model(user).
+			Where("deleted_at = ?", user.DeletedAt).
+			Updates(map[string]interface{}{
+				"deleted_at": 0,
+				"version":    gorm.Expr("version + 1"),
+			})
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			// Taken concurrently, or only differing in case where = is case-sensitive
+			return ErrConflict
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotDeleted
+		}
+
+		user.DeletedAt = 0
+		user.Version++
+		return nil
+	})
+}
+
+// HardDelete permanently removes users together with their role assignments
+// and refresh tokens
+func HardDelete(db *gorm.DB, ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteDependents(tx, ids); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&models.User{}, ids).Error; err != nil {
+			return fmt.Errorf("failed to delete users: %v", err)
+		}
+		return nil
+	})
+}
+
+// deleteDependents removes the role assignments and refresh tokens of users
+func deleteDependents(tx *gorm.DB, ids []uint) error {
+	if err := tx.Where("user_id IN ?", ids).Delete(&models.RefreshToken{}).Error; err != nil {
+		return fmt.Errorf("failed to delete refresh tokens: %v", err)
+	}
+	if err := tx.Exec("DELETE FROM user_roles WHERE user_id IN ?", ids).Error; err != nil {
+		return fmt.Errorf("failed to delete role assignments: %v", err)
+	}
+	return nil
+}
+
+// Purge permanently removes users that were soft-deleted more than retention
+// ago and returns how many were removed
+func Purge(db *gorm.DB, retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention).UnixMilli()
+	purged := 0
+
+	for {
+		found, deleted, err := purgeBatch(db, cutoff)
+		purged += deleted
+		if err != nil || found == 0 {
+			return purged, err
+		}
+	}
+}
+
+// purgeBatch permanently removes up to purgeBatchSize users soft-deleted
+// before cutoff and returns how many it found and deleted. The users are
+// locked until the transaction ends and deleted only if they are still
+// expired, so a user restored meanwhile keeps its roles and sessions.
+func purgeBatch(db *gorm.DB, cutoff int64) (found, deleted int, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		expired := func() *gorm.DB {
+			return Deleted(tx).Model(&models.User{}).Where("deleted_at < ?", cutoff)
+		}
+
+		var ids []uint
+		err := expired().
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Limit(purgeBatchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return fmt.Errorf("failed to find expired users: %v", err)
+		}
+		found = len(ids)
+		if found == 0 {
+			return nil
+		}
+
+		if err := deleteDependents(tx, ids); err != nil {
+			return err
+		}
+		result := expired().Where("id IN ?", ids).Delete(&models.User{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete users: %v", result.Error)
+		}
+		deleted = int(result.RowsAffected)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return found, deleted, nil
+}
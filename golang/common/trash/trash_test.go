@@ -0,0 +1,81 @@
+package trash_test
+
+import (
+	"testing"
+	"time"
+
+	"common/internal/testdb"
+	"common/models"
+	"common/rbac"
+	"common/trash"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/soft_delete"
+)
+
+// createUser stores a user deleted at deletedAt (0 for active) with a role
+// and a refresh token
+func createUser(t *testing.T, db *gorm.DB, name string, deletedAt time.Time) *models.User {
+	t.Helper()
+
+	user := &models.User{Email: name + "@example.com", Username: name, Password: "hash"}
+	if !deletedAt.IsZero() {
+		user.DeletedAt = soft_delete.DeletedAt(deletedAt.UnixMilli())
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := rbac.SetUserRoles(db, user, []string{"user"}); err != nil {
+		t.Fatal(err)
+	}
+	token := &models.RefreshToken{UserID: user.ID, FamilyID: name, TokenHash: name, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := db.Create(token).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestPurge(t *testing.T) {
+	db := testdb.Open(t)
+	now := time.Now()
+
+	expired := createUser(t, db, "expired", now.Add(-48*time.Hour))
+	recent := createUser(t, db, "recent", now.Add(-time.Hour))
+	active := createUser(t, db, "active", time.Time{})
+
+	n, err := trash.Purge(db, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("purged %d users, want 1", n)
+	}
+
+	tests := []struct {
+		name string
+		user *models.User
+		kept bool
+	}{
+		{"expired", expired, false},
+		{"recently deleted", recent, true},
+		{"active", active, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts := map[string]*gorm.DB{
+				"users":          db.Unscoped().Model(&models.User{}).Where("id = ?", tt.user.ID),
+				"user_roles":     db.Table("user_roles").Where("user_id = ?", tt.user.ID),
+				"refresh_tokens": db.Model(&models.RefreshToken{}).Where("user_id = ?", tt.user.ID),
+			}
+			for table, q := range counts {
+				var n int64
+				if err := q.Count(&n).Error; err != nil {
+					t.Fatal(err)
+				}
+				if kept := n > 0; kept != tt.kept {
+					t.Errorf("%s rows kept: %v, want %v", table, kept, tt.kept)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+// DefaultMaxBodySize is the request body limit when MAX_BODY_SIZE is not set
+const DefaultMaxBodySize = 1 << 20
+
+// ErrBodyTooLarge is returned for a request body over the size limit
+var ErrBodyTooLarge = errors.New("request body is too large")
+
+var maxBodySize atomic.Int64
+
+func init() {
+	maxBodySize.Store(DefaultMaxBodySize)
+}
+
+// MaxBodySizeFromEnv reads MAX_BODY_SIZE, the largest accepted body in
+// bytes, falling back to DefaultMaxBodySize
+func MaxBodySizeFromEnv() (int64, error) {
+	v := os.Getenv("MAX_BODY_SIZE")
+	if v == "" {
+		return DefaultMaxBodySize, nil
+	}
+
+	size, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || size <= 0 {
+		return DefaultMaxBodySize, fmt.Errorf("invalid MAX_BODY_SIZE %q: must be a positive number of bytes", v)
+	}
+	return size, nil
+}
+
+// SetMaxBodySize sets the largest accepted request body in bytes
+func SetMaxBodySize(size int64) {
+	maxBodySize.Store(size)
+}
+
+// MaxBodySize returns the largest accepted request body in bytes
+func MaxBodySize() int64 {
+	return maxBodySize.Load()
+}
+
+// ReadBody reads a request body up to the size limit
+func ReadBody(r io.Reader) ([]byte, error) {
+	limit := MaxBodySize()
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBody, err)
+	}
+	if int64(len(body)) > limit {
+		return nil, ErrBodyTooLarge
+	}
+	return body, nil
+}
@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"bytes"
+	"common/problem"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ErrInvalidBody is returned for a request body that is not a JSON object
+var ErrInvalidBody = errors.New("request body must be a valid JSON object")
+
+// Decode strictly decodes a JSON object into the struct v points to. Every
+// member v does not declare and every value of the wrong type is listed in
+// an Error; member names are matched exactly, not case-insensitively.
+func Decode(body []byte, v interface{}) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBody, err)
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	fields := map[string]reflect.Value{}
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if field.IsExported() && field.Tag.Get("json") != "-" {
+			fields[jsonName(field)] = rv.Field(i)
+		}
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs Error
+	for _, name := range names {
+		field, ok := fields[name]
+		if !ok {
+			errs.Fields = append(errs.Fields, problem.FieldError{
+				Field:   name,
+				Code:    problem.FieldUnknown,
+				Message: name + " is not a known field",
+			})
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(members[name]))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(field.Addr().Interface()); err != nil {
+			errs.Fields = append(errs.Fields, problem.FieldError{
+				Field:   name,
+				Code:    problem.FieldInvalidType,
+				Message: name + " must be " + typeName(field.Type()),
+			})
+		}
+	}
+
+	if len(errs.Fields) > 0 {
+		return &errs
+	}
+	return nil
+}
+
+// typeName describes the JSON type of a Go type for error messages
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeName(t.Elem())
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+	return "a number"
+}
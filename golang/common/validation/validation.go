@@ -0,0 +1,216 @@
+// Package validation is the request validation layer shared by every
+// framework. Request DTOs declare their rules in validate tags, the same
+// tags the OpenAPI document is generated from, so the documented and the
+// enforced constraints cannot drift apart.
+package validation
+
+import (
+	"common/problem"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Error lists the fields of a request that failed validation
+type Error struct {
+	Fields []problem.FieldError
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		messages = append(messages, f.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Fail returns an Error for a single field
+func Fail(field, code, message string) error {
+	return &Error{Fields: []problem.FieldError{{Field: field, Code: code, Message: message}}}
+}
+
+// Struct validates every field of the struct v points to against its
+// validate tag. Each failing field is listed once, with the first rule it
+// breaks.
+func Struct(v interface{}) error {
+	return check(v, nil)
+}
+
+// Fields validates only the fields of v with the given JSON names, e.g. the
+// fields a partial update changes
+func Fields(v interface{}, names ...string) error {
+	only := make(map[string]bool, len(names))
+	for _, name := range names {
+		only[name] = true
+	}
+	return check(v, only)
+}
+
+func check(v interface{}, only map[string]bool) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	t := rv.Type()
+
+	var errs Error
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		if only != nil && !only[name] {
+			continue
+		}
+		if f := checkValue(name, rv.Field(i), tag); f != nil {
+			errs.Fields = append(errs.Fields, *f)
+		}
+	}
+
+	if len(errs.Fields) > 0 {
+		return &errs
+	}
+	return nil
+}
+
+// checkValue applies the rules of a tag to a field value and returns the
+// first one it breaks
+func checkValue(name string, value reflect.Value, tag string) *problem.FieldError {
+	fail := func(code, format string, args ...interface{}) *problem.FieldError {
+		return &problem.FieldError{Field: name, Code: code, Message: name + " " + fmt.Sprintf(format, args...)}
+	}
+
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if hasRule(tag, "required") {
+				return fail(problem.FieldRequired, "is required")
+			}
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		rule, arg, _ := strings.Cut(rule, "=")
+		switch rule {
+		case "required":
+			if isEmpty(value) {
+				return fail(problem.FieldRequired, "is required")
+			}
+		case "omitempty":
+			if isEmpty(value) {
+				return nil
+			}
+		case "email":
+			s := value.String()
+			addr, err := mail.ParseAddress(s)
+			if err != nil || addr.Address != s {
+				return fail(problem.FieldInvalidEmail, "must be a valid email address")
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				panic(fmt.Sprintf("validation: rule %q of %s: %v", rule, name, err))
+			}
+			length, unit := lengthOf(value)
+			if rule == "min" && length < n {
+				return fail(problem.FieldTooShort, "must be at least %d %s", n, unit)
+			}
+			if rule == "max" && length > n {
+				return fail(problem.FieldTooLong, "must be at most %d %s", n, unit)
+			}
+		case "oneof":
+			if !contains(strings.Fields(arg), value.String()) {
+				return fail(problem.FieldInvalidValue, "must be one of %s", strings.Join(strings.Fields(arg), ", "))
+			}
+		case "username":
+			if !isUsername(value.String()) {
+				return fail(problem.FieldInvalidFormat, "may only contain letters, digits, '.', '_' and '-'")
+			}
+		case "password":
+			if !isStrongPassword(value.String()) {
+				return fail(problem.FieldWeakPassword, "must contain at least one letter and one digit")
+			}
+		default:
+			panic(fmt.Sprintf("validation: unknown rule %q of %s", rule, name))
+		}
+	}
+	return nil
+}
+
+// UsernamePattern is the character set of usernames as a regular expression
+const UsernamePattern = `^[A-Za-z0-9._-]+$`
+
+func isUsername(s string) bool {
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isStrongPassword reports whether a password mixes letters and digits. Its
+// length is checked by the min and max rules.
+func isStrongPassword(s string) bool {
+	var letter, digit bool
+	for _, c := range s {
+		letter = letter || unicode.IsLetter(c)
+		digit = digit || unicode.IsDigit(c)
+	}
+	return letter && digit
+}
+
+func hasRule(tag, rule string) bool {
+	for _, r := range strings.Split(tag, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmpty reports whether a value is missing: a blank string or a nil slice.
+// An empty slice is a value, e.g. a role list that removes every role.
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.IsNil()
+	}
+	return value.IsZero()
+}
+
+// lengthOf returns the length min and max compare: characters for strings
+// and items for slices
+func lengthOf(value reflect.Value) (int, string) {
+	if value.Kind() == reflect.String {
+		return utf8.RuneCountInString(value.String()), "characters"
+	}
+	return value.Len(), "items"
+}
+
+func contains(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonName returns the name of a field in JSON
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,860 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// step is one request of the conformance table. Every variant receives the
+// same steps in the same order, so ids and versions line up across servers.
+type step struct {
+	name   string
+	method string
+	path   string
+	// header holds extra request headers
+	header map[string]string
+	body   string
+	// anonymous sends the request without the admin token
+	anonymous bool
+	// follow walks the rel="next" links of the response to the last page
+	follow bool
+	// status is the status code every variant must answer with
+	status int
+	// check runs extra assertions on the response of each variant
+	check func(t *testing.T, variant string, r *response)
+}
+
+// response is what a variant answered to a step, reduced to the parts that
+// must match across variants
+type response struct {
+	Status  int
+	Body    interface{}
+	Headers map[string]string
+	raw     http.Header
+	rawBody []byte
+}
+
+// comparedHeaders are response headers whose values must match exactly
+var comparedHeaders = []string{"ETag", "Link", "Accept-Patch"}
+
+// volatileFields hold timestamps, tokens, request IDs and measurements that
+// differ between runs; only their presence is compared
+var volatileFields = map[string]bool{
+	"created_at":         true,
+	"updated_at":         true,
+	"deleted_at":         true,
+	"access_token":       true,
+	"refresh_token":      true,
+	"expires_in":         true,
+	"refresh_expires_in": true,
+	"request_id":         true,
+	// Readiness measurements: ping time and connection pool counts
+	"details": true,
+}
+
+var steps = []step{
+	{
+		name:   "create",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":"alice@example.com","username":"alice","password":"password123"}`,
+		status: http.StatusCreated,
+	},
+	{
+		name:   "create second",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":"bob@example.com","username":"bob","password":"password123"}`,
+		status: http.StatusCreated,
+	},
+	{
+		name:   "create duplicate email",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":"Alice@Example.com","username":"alice2","password":"password123"}`,
+		status: http.StatusConflict,
+	},
+	{
+		name:   "create duplicate username",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":"alice2@example.com","username":"alice","password":"password123"}`,
+		status: http.StatusConflict,
+	},
+	{
+		name:   "create invalid email",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":"not-an-email","username":"carol","password":"password123"}`,
+		status: http.StatusBadRequest,
+	},
+	{
+		name:   "create with several invalid fields",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":"carol.example.com","username":"carol smith","password":"short"}`,
+		status: http.StatusBadRequest,
+		check:  checkFieldErrors("email:invalid_email", "username:invalid_format", "password:too_short"),
+	},
+	{
+		name:   "create weak password",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":"carol@example.com","username":"carol","password":"onlyletters"}`,
+		status: http.StatusBadRequest,
+		check:  checkFieldErrors("password:weak_password"),
+	},
+	{
+		name:   "create username too long",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":"carol@example.com","username":"` + strings.Repeat("c", 51) + `","password":"password123"}`,
+		status: http.StatusBadRequest,
+		check:  checkFieldErrors("username:too_long"),
+	},
+	{
+		name:   "create unknown and mistyped fields",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":"carol@example.com","username":42,"password":"password123","role":"admin"}`,
+		status: http.StatusBadRequest,
+		check:  checkFieldErrors("role:unknown_field", "username:invalid_type"),
+	},
+	{
+		name:   "create body too large",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":"` + strings.Repeat("a", 5000) + `@example.com"}`,
+		status: http.StatusRequestEntityTooLarge,
+	},
+	{
+		name:   "create malformed body",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":`,
+		status: http.StatusBadRequest,
+	},
+	{
+		name:      "login missing password",
+		method:    http.MethodPost,
+		path:      "/api/auth/login",
+		body:      `{"username":"alice"}`,
+		anonymous: true,
+		status:    http.StatusBadRequest,
+	},
+	{
+		name:   "list",
+		method: http.MethodGet,
+		path:   "/api/users?per_page=2",
+		status: http.StatusOK,
+	},
+	{
+		name:   "list every page",
+		method: http.MethodGet,
+		path:   "/api/users?per_page=1&sort=-username",
+		follow: true,
+		status: http.StatusOK,
+		check:  checkPages("bob", "alice", "admin"),
+	},
+	{
+		name:   "list malformed query",
+		method: http.MethodGet,
+		path:   "/api/users?per_page=%zz",
+		status: http.StatusBadRequest,
+	},
+	{
+		name:   "get",
+		method: http.MethodGet,
+		path:   "/api/users/2",
+		status: http.StatusOK,
+	},
+	{
+		name:   "get invalid id",
+		method: http.MethodGet,
+		path:   "/api/users/abc",
+		status: http.StatusBadRequest,
+	},
+	{
+		name:   "get not found",
+		method: http.MethodGet,
+		path:   "/api/users/999",
+		status: http.StatusNotFound,
+	},
+	{
+		name:      "get unauthenticated",
+		method:    http.MethodGet,
+		path:      "/api/users/2",
+		anonymous: true,
+		status:    http.StatusUnauthorized,
+	},
+	{
+		name:   "update",
+		method: http.MethodPut,
+		path:   "/api/users/2",
+		header: map[string]string{"If-Match": `"2-1"`},
+		body:   `{"email":"alice@example.org","username":"alice","password":"password456"}`,
+		status: http.StatusOK,
+	},
+	{
+		name:   "update stale",
+		method: http.MethodPut,
+		path:   "/api/users/2",
+		header: map[string]string{"If-Match": `"2-1"`},
+		body:   `{"email":"alice@example.net","username":"alice","password":"password456"}`,
+		status: http.StatusPreconditionFailed,
+	},
+	{
+		name:   "get not modified",
+		method: http.MethodGet,
+		path:   "/api/users/2",
+		header: map[string]string{"If-None-Match": `"2-2"`},
+		status: http.StatusNotModified,
+		check:  checkNotModified(`"2-2"`),
+	},
+	{
+		name:   "get modified since stale etag",
+		method: http.MethodGet,
+		path:   "/api/users/2",
+		header: map[string]string{"If-None-Match": `"2-1"`},
+		status: http.StatusOK,
+		check:  checkETag(`"2-2"`),
+	},
+	{
+		name:   "update duplicate",
+		method: http.MethodPut,
+		path:   "/api/users/2",
+		body:   `{"email":"bob@example.com","username":"alice","password":"password456"}`,
+		status: http.StatusConflict,
+	},
+	{
+		name:   "update invalid id",
+		method: http.MethodPut,
+		path:   "/api/users/abc",
+		body:   `{"email":"alice@example.org","username":"alice","password":"password456"}`,
+		status: http.StatusBadRequest,
+	},
+	{
+		name:   "patch",
+		method: http.MethodPatch,
+		path:   "/api/users/2",
+		header: map[string]string{"Content-Type": "application/merge-patch+json"},
+		body:   `{"username":"alicia"}`,
+		status: http.StatusOK,
+	},
+	{
+		name:   "patch unsupported media type",
+		method: http.MethodPatch,
+		path:   "/api/users/2",
+		header: map[string]string{"Content-Type": "application/xml"},
+		body:   `<user/>`,
+		status: http.StatusUnsupportedMediaType,
+	},
+	{
+		name:   "delete invalid hard",
+		method: http.MethodDelete,
+		path:   "/api/users/3?hard=yes",
+		status: http.StatusBadRequest,
+	},
+	{
+		name:   "delete",
+		method: http.MethodDelete,
+		path:   "/api/users/3",
+		status: http.StatusOK,
+	},
+	{
+		name:   "get deleted",
+		method: http.MethodGet,
+		path:   "/api/users/3",
+		status: http.StatusNotFound,
+	},
+	{
+		name:   "delete again",
+		method: http.MethodDelete,
+		path:   "/api/users/3",
+		status: http.StatusNotFound,
+	},
+	{
+		name:   "trash",
+		method: http.MethodGet,
+		path:   "/api/users/trash",
+		status: http.StatusOK,
+	},
+	{
+		name:   "restore",
+		method: http.MethodPost,
+		path:   "/api/users/3/restore",
+		status: http.StatusOK,
+	},
+	{
+		name:      "unknown route",
+		method:    http.MethodGet,
+		path:      "/api/nope",
+		anonymous: true,
+		status:    http.StatusNotFound,
+	},
+	{
+		name:      "method not allowed",
+		method:    http.MethodDelete,
+		path:      "/api/users",
+		anonymous: true,
+		status:    http.StatusMethodNotAllowed,
+	},
+	{
+		name:   "request id",
+		method: http.MethodGet,
+		path:   "/api/users/999",
+		header: map[string]string{"X-Request-ID": "conformance-1"},
+		status: http.StatusNotFound,
+		check:  checkRequestID,
+	},
+	{
+		name:   "trace context",
+		method: http.MethodGet,
+		path:   "/api/users/2",
+		header: map[string]string{"traceparent": "00-" + traceID + "-" + parentSpanID + "-01"},
+		status: http.StatusOK,
+	},
+	{
+		name:      "openapi document",
+		method:    http.MethodGet,
+		path:      "/openapi.json",
+		anonymous: true,
+		status:    http.StatusOK,
+	},
+	{
+		name:      "docs page",
+		method:    http.MethodGet,
+		path:      "/docs",
+		anonymous: true,
+		status:    http.StatusOK,
+	},
+	{
+		name:      "docs asset",
+		method:    http.MethodGet,
+		path:      "/docs/swagger-ui.css",
+		anonymous: true,
+		status:    http.StatusOK,
+	},
+	{
+		name:      "docs missing asset",
+		method:    http.MethodGet,
+		path:      "/docs/missing.js",
+		anonymous: true,
+		status:    http.StatusNotFound,
+	},
+	{
+		name:      "docs unlisted asset",
+		method:    http.MethodGet,
+		path:      "/docs/swagger-initializer.js",
+		anonymous: true,
+		status:    http.StatusNotFound,
+	},
+	{
+		name:      "liveness",
+		method:    http.MethodGet,
+		path:      "/healthz",
+		anonymous: true,
+		status:    http.StatusOK,
+	},
+	{
+		name:      "readiness",
+		method:    http.MethodGet,
+		path:      "/readyz",
+		anonymous: true,
+		status:    http.StatusOK,
+	},
+	{
+		name:   "preflight",
+		method: http.MethodOptions,
+		path:   "/api/users/2",
+		header: map[string]string{
+			"Origin":                         "http://example.com",
+			"Access-Control-Request-Method":  "PATCH",
+			"Access-Control-Request-Headers": "If-Match, Content-Type",
+		},
+		anonymous: true,
+		status:    http.StatusNoContent,
+		check:     checkPreflight,
+	},
+}
+
+// TestConformance runs the step table against every variant and fails on
+// any difference in status, JSON body or compared headers
+func TestConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and starts every server")
+	}
+
+	bins := buildServers(t)
+
+	results := make(map[string][]*response, len(variants))
+	for _, variant := range variants {
+		s := startServer(t, variant, bins[variant])
+		token := setup(t, s)
+
+		for _, st := range steps {
+			do := s.do
+			if st.follow {
+				do = s.walk
+			}
+			r, err := do(st, token)
+			if err != nil {
+				t.Fatalf("%s %s: %v", variant, st.name, err)
+			}
+			results[variant] = append(results[variant], r)
+		}
+	}
+
+	reference := variants[0]
+	for i, st := range steps {
+		i, st := i, st
+		t.Run(st.name, func(t *testing.T) {
+			for _, variant := range variants {
+				r := results[variant][i]
+				if r.Status != st.status {
+					t.Errorf("%s: status %d, want %d\nbody: %s", variant, r.Status, st.status, dump(r.Body))
+				}
+				if st.check != nil {
+					st.check(t, variant, r)
+				}
+			}
+
+			want := results[reference][i]
+			for _, variant := range variants[1:] {
+				got := results[variant][i]
+				if got.Status != want.Status {
+					t.Errorf("%s answered %d, %s answered %d", variant, got.Status, reference, want.Status)
+				}
+				if !reflect.DeepEqual(got.Headers, want.Headers) {
+					t.Errorf("headers differ\n%s: %v\n%s: %v", reference, want.Headers, variant, got.Headers)
+				}
+				if !reflect.DeepEqual(got.Body, want.Body) {
+					t.Errorf("bodies differ\n%s: %s\n%s: %s", reference, dump(want.Body), variant, dump(got.Body))
+				}
+			}
+		})
+	}
+}
+
+// setup creates the admin user (id 1) and returns its access token
+func setup(t *testing.T, s *server) string {
+	t.Helper()
+
+	admin := step{
+		name:   "create admin",
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   `{"email":"admin@example.com","username":"admin","password":"password123"}`,
+	}
+	if r, err := s.do(admin, ""); err != nil || r.Status != http.StatusCreated {
+		t.Fatalf("%s: create admin: %v %v", s.name, err, r)
+	}
+
+	s.command(t, "grant-role", "admin@example.com", "admin")
+
+	login := step{
+		name:   "login",
+		method: http.MethodPost,
+		path:   "/api/auth/login",
+		body:   `{"email":"admin@example.com","password":"password123"}`,
+	}
+	req, err := s.request(login, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s: login: %v", s.name, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Data struct {
+			AccessToken string `json:"access_token"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Data.AccessToken == "" {
+		t.Fatalf("%s: login: status %d, %v", s.name, resp.StatusCode, err)
+	}
+	return body.Data.AccessToken
+}
+
+// request builds the HTTP request of a step
+func (s *server) request(st step, token string) (*http.Request, error) {
+	var body io.Reader
+	if st.body != "" {
+		body = strings.NewReader(st.body)
+	}
+
+	req, err := http.NewRequest(st.method, s.base+st.path, body)
+	if err != nil {
+		return nil, err
+	}
+	if st.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" && !st.anonymous {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, v := range st.header {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// do sends a step and reduces the response
+func (s *server) do(st step, token string) (*response, error) {
+	req, err := s.request(st, token)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &response{
+		Status:  resp.StatusCode,
+		Headers: map[string]string{},
+		raw:     resp.Header,
+		rawBody: raw,
+	}
+	for _, name := range comparedHeaders {
+		if v := resp.Header.Get(name); v != "" {
+			r.Headers[name] = v
+		}
+	}
+
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return r, nil
+	}
+
+	// Charset parameters differ between frameworks; only the media type counts
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("Content-Type %q: %v", resp.Header.Get("Content-Type"), err)
+	}
+	r.Headers["Content-Type"] = mediaType
+
+	// Other bodies (the documentation page and its assets) are compared as text
+	if !isJSON(mediaType) {
+		r.Body = string(raw)
+		return r, nil
+	}
+	if err := json.Unmarshal(raw, &r.Body); err != nil {
+		return nil, fmt.Errorf("body is not JSON: %v\n%s", err, raw)
+	}
+	r.Body = normalize(r.Body)
+	return r, nil
+}
+
+// maxPages bounds how many pages walk follows
+const maxPages = 20
+
+// walk sends a step and follows the rel="next" links of the responses. It
+// returns the last page with Body holding the bodies of every page in order.
+func (s *server) walk(st step, token string) (*response, error) {
+	var pages []interface{}
+	for {
+		r, err := s.do(st, token)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, r.Body)
+
+		next := link(r.raw.Get("Link"), "next")
+		if next == "" || r.Status != http.StatusOK {
+			r.Body = pages
+			return r, nil
+		}
+		if len(pages) == maxPages {
+			return nil, fmt.Errorf("%s: more than %d pages", st.path, maxPages)
+		}
+		st.path = next
+	}
+}
+
+// link returns the target of the rel link in a Link header, or ""
+func link(header, rel string) string {
+	for _, l := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(l), ";")
+		if ok && strings.TrimSpace(params) == `rel="`+rel+`"` {
+			return strings.Trim(target, "<>")
+		}
+	}
+	return ""
+}
+
+// normalize replaces the values of volatile fields with a placeholder
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if volatileFields[k] {
+				v[k] = "<" + k + ">"
+				continue
+			}
+			v[k] = normalize(field)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = normalize(v[i])
+		}
+	}
+	return v
+}
+
+// checkRequestID asserts that the request ID sent by the client is echoed
+// in the response header and the problem body
+func checkRequestID(t *testing.T, variant string, r *response) {
+	t.Helper()
+
+	if got := r.raw.Get("X-Request-ID"); got != "conformance-1" {
+		t.Errorf("%s: X-Request-ID %q, want conformance-1", variant, got)
+	}
+	var body struct {
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(r.rawBody, &body); err != nil || body.RequestID != "conformance-1" {
+		t.Errorf("%s: request_id %q, want conformance-1", variant, body.RequestID)
+	}
+}
+
+// The trace context the "trace context" step continues
+const (
+	traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID = "00f067aa0ba902b7"
+)
+
+// span is the part of a span the console exporter writes that is checked
+type span struct {
+	Name        string
+	SpanContext struct{ TraceID, SpanID string }
+	Parent      struct{ SpanID string }
+	Attributes  []struct{ Key string }
+}
+
+// checkTraces checks the spans a server exported to file: the request of
+// the "trace context" step continued the caller's trace in a server span
+// with its route, and the queries it ran are children of that span
+func checkTraces(t *testing.T, variant, file string) {
+	t.Helper()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Errorf("%s: %v", variant, err)
+		return
+	}
+	var spans []span
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var s span
+		if err := dec.Decode(&s); err != nil {
+			t.Errorf("%s: %s: %v", variant, filepath.Base(file), err)
+			return
+		}
+		spans = append(spans, s)
+	}
+
+	var server *span
+	for i, s := range spans {
+		if s.SpanContext.TraceID == traceID && s.Parent.SpanID == parentSpanID {
+			server = &spans[i]
+		}
+	}
+	if server == nil {
+		t.Errorf("%s: no server span continues trace %s", variant, traceID)
+		return
+	}
+	if !hasAttribute(*server, "http.route") || !strings.HasPrefix(server.Name, "GET /api/users/") {
+		t.Errorf("%s: server span %q has no route", variant, server.Name)
+	}
+
+	queries := 0
+	for _, s := range spans {
+		if s.Parent.SpanID == server.SpanContext.SpanID && hasAttribute(s, "db.statement") {
+			queries++
+		}
+	}
+	if queries == 0 {
+		t.Errorf("%s: server span %q has no query spans", variant, server.Name)
+	}
+}
+
+// logLine is the part of a JSON log line that is checked
+type logLine struct {
+	Msg       string
+	Route     string
+	Status    int
+	RequestID string `json:"request_id"`
+	SQL       string
+}
+
+// checkLogs checks the JSON log a server wrote: the request of the "request
+// id" step has an access log line carrying its ID, and queries are logged
+// without their bound values
+func checkLogs(t *testing.T, variant, output string) {
+	t.Helper()
+
+	accessLogged, queries := false, 0
+	for _, raw := range strings.Split(output, "\n") {
+		var line logLine
+		if !strings.HasPrefix(raw, "{") || json.Unmarshal([]byte(raw), &line) != nil {
+			continue
+		}
+		switch line.Msg {
+		case "request":
+			if line.RequestID == "conformance-1" {
+				accessLogged = true
+				if line.Status != http.StatusNotFound || line.Route != "/api/users/:id" {
+					t.Errorf("%s: access log of request conformance-1 has status %d and route %q", variant, line.Status, line.Route)
+				}
+			}
+		case "query":
+			queries++
+			if strings.Contains(line.SQL, "alice@example.com") {
+				t.Errorf("%s: query logged with its values: %s", variant, line.SQL)
+			}
+		}
+	}
+	if !accessLogged {
+		t.Errorf("%s: no access log line for request conformance-1", variant)
+	}
+	if queries == 0 {
+		t.Errorf("%s: no queries logged at debug level", variant)
+	}
+}
+
+func hasAttribute(s span, key string) bool {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFieldErrors returns a check that the problem lists exactly the given
+// "field:code" errors, in order
+func checkFieldErrors(want ...string) func(t *testing.T, variant string, r *response) {
+	return func(t *testing.T, variant string, r *response) {
+		t.Helper()
+
+		var body struct {
+			Errors []struct {
+				Field string `json:"field"`
+				Code  string `json:"code"`
+			} `json:"errors"`
+		}
+		if err := json.Unmarshal(r.rawBody, &body); err != nil {
+			t.Errorf("%s: %v", variant, err)
+			return
+		}
+		got := make([]string, 0, len(body.Errors))
+		for _, e := range body.Errors {
+			got = append(got, e.Field+":"+e.Code)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: errors %v, want %v", variant, got, want)
+		}
+	}
+}
+
+// checkPages returns a check that a walked list has one user per page,
+// named in order
+func checkPages(want ...string) func(t *testing.T, variant string, r *response) {
+	return func(t *testing.T, variant string, r *response) {
+		t.Helper()
+
+		pages, _ := r.Body.([]interface{})
+		var got []string
+		for _, page := range pages {
+			body, _ := page.(map[string]interface{})
+			users, _ := body["data"].([]interface{})
+			if len(users) != 1 {
+				t.Errorf("%s: page of %d users, want 1", variant, len(users))
+				continue
+			}
+			user, _ := users[0].(map[string]interface{})
+			got = append(got, fmt.Sprint(user["username"]))
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: pages %v, want %v", variant, got, want)
+		}
+	}
+}
+
+// checkETag returns a check that the response carries the ETag
+func checkETag(want string) func(t *testing.T, variant string, r *response) {
+	return func(t *testing.T, variant string, r *response) {
+		t.Helper()
+
+		if got := r.raw.Get("ETag"); got != want {
+			t.Errorf("%s: ETag %q, want %q", variant, got, want)
+		}
+	}
+}
+
+// checkNotModified returns a check that a 304 carries the ETag and no body
+func checkNotModified(etag string) func(t *testing.T, variant string, r *response) {
+	return func(t *testing.T, variant string, r *response) {
+		t.Helper()
+
+		checkETag(etag)(t, variant, r)
+		if len(r.rawBody) != 0 {
+			t.Errorf("%s: 304 with body %q", variant, r.rawBody)
+		}
+	}
+}
+
+// checkPreflight asserts the CORS headers every variant must send. Their
+// exact formatting differs between the CORS middlewares, so only the content
+// is checked.
+func checkPreflight(t *testing.T, variant string, r *response) {
+	t.Helper()
+
+	if got := r.raw.Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("%s: Access-Control-Allow-Origin %q, want *", variant, got)
+	}
+	if !containsToken(r.raw.Get("Access-Control-Allow-Methods"), "PATCH") {
+		t.Errorf("%s: Access-Control-Allow-Methods %q does not allow PATCH", variant, r.raw.Get("Access-Control-Allow-Methods"))
+	}
+	for _, header := range []string{"If-Match", "Content-Type"} {
+		if !containsToken(r.raw.Get("Access-Control-Allow-Headers"), header) {
+			t.Errorf("%s: Access-Control-Allow-Headers %q does not allow %s", variant, r.raw.Get("Access-Control-Allow-Headers"), header)
+		}
+	}
+}
+
+// containsToken reports whether a comma-separated header value contains
+// token, ignoring case
+func containsToken(list, token string) bool {
+	for _, v := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// dump formats a decoded body for failure messages
+func dump(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,258 @@
+package conformance
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// contractSteps reach the documented operations the conformance table
+// leaves out. They run after steps, so they see the state steps left behind.
+var contractSteps = []step{
+	{
+		name:      "health",
+		method:    http.MethodGet,
+		path:      "/",
+		anonymous: true,
+	},
+	{
+		name:      "metrics",
+		method:    http.MethodGet,
+		path:      "/metrics",
+		anonymous: true,
+		check:     checkMetrics,
+	},
+	{
+		name:   "get not modified",
+		method: http.MethodGet,
+		path:   "/api/users/2",
+		header: map[string]string{"If-None-Match": "*"},
+	},
+	{
+		name:   "list roles",
+		method: http.MethodGet,
+		path:   "/api/roles",
+	},
+	{
+		name:   "get user roles",
+		method: http.MethodGet,
+		path:   "/api/users/2/roles",
+	},
+	{
+		name:   "set user roles",
+		method: http.MethodPut,
+		path:   "/api/users/2/roles",
+		body:   `{"roles":["support"]}`,
+	},
+	{
+		name:   "set unknown role",
+		method: http.MethodPut,
+		path:   "/api/users/2/roles",
+		body:   `{"roles":["owner"]}`,
+	},
+	{
+		name:      "login",
+		method:    http.MethodPost,
+		path:      "/api/auth/login",
+		body:      `{"username":"alicia","password":"password456"}`,
+		anonymous: true,
+	},
+	{
+		name:      "login wrong password",
+		method:    http.MethodPost,
+		path:      "/api/auth/login",
+		body:      `{"username":"alicia","password":"password123"}`,
+		anonymous: true,
+	},
+	{
+		name:      "refresh invalid token",
+		method:    http.MethodPost,
+		path:      "/api/auth/refresh",
+		body:      `{"refresh_token":"invalid"}`,
+		anonymous: true,
+	},
+	{
+		name:      "logout invalid token",
+		method:    http.MethodPost,
+		path:      "/api/auth/logout",
+		body:      `{"refresh_token":"invalid"}`,
+		anonymous: true,
+	},
+	{
+		name:   "revoke sessions",
+		method: http.MethodDelete,
+		path:   "/api/users/2/sessions",
+	},
+	{
+		name:   "hard delete",
+		method: http.MethodDelete,
+		path:   "/api/users/3?hard=true",
+	},
+	{
+		name:   "restore missing",
+		method: http.MethodPost,
+		path:   "/api/users/3/restore",
+	},
+	{
+		name:   "list trash sorted",
+		method: http.MethodGet,
+		path:   "/api/users/trash?sort=-deleted_at&per_page=1",
+	},
+	{
+		name:   "list invalid sort",
+		method: http.MethodGet,
+		path:   "/api/users?sort=password",
+	},
+}
+
+// TestContract sends every request of both tables to each variant and
+// validates the responses against the status codes and schemas of the
+// OpenAPI document, and of the document the variant generates itself at
+// /openapi.json. Fields the schemas do not declare fail the test, and so
+// does any documented operation no request reaches.
+func TestContract(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and starts every server")
+	}
+
+	doc, err := loadSpec(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bins := buildServers(t)
+	table := append(append([]step{}, steps...), contractSteps...)
+
+	exercised := map[string]bool{}
+	for _, variant := range variants {
+		s := startServer(t, variant, bins[variant])
+		token := setup(t, s)
+		generated := servedSpec(t, s, doc)
+
+		for _, st := range table {
+			// CORS preflights are answered by middleware, not by an API operation
+			if st.method == http.MethodOptions {
+				continue
+			}
+
+			// Walked lists are validated by their last page
+			do := s.do
+			if st.follow {
+				do = s.walk
+			}
+			r, err := do(st, token)
+			if err != nil {
+				t.Fatalf("%s %s: %v", variant, st.name, err)
+			}
+
+			path := strings.SplitN(st.path, "?", 2)[0]
+			template, ok := doc.match(path)
+			key := st.method + " " + template
+			if _, documented := doc.operations[key]; !ok || !documented {
+				// Route misses are answered by the router, not by an operation
+				if st.status == http.StatusNotFound || st.status == http.StatusMethodNotAllowed {
+					for _, problem := range doc.checkProblem(r, r.rawBody) {
+						t.Errorf("%s: %s (%s %s -> %d): %s", variant, st.name, st.method, st.path, r.Status, problem)
+					}
+					continue
+				}
+				t.Errorf("%s: %s: undocumented endpoint %s %s", variant, st.name, st.method, path)
+				continue
+			}
+			exercised[key] = true
+
+			for _, problem := range doc.checkResponse(key, r, r.rawBody) {
+				t.Errorf("%s: %s (%s %s -> %d): %s", variant, st.name, st.method, st.path, r.Status, problem)
+			}
+			for _, problem := range generated.checkResponse(key, r, r.rawBody) {
+				t.Errorf("%s: %s (%s %s -> %d): /openapi.json: %s", variant, st.name, st.method, st.path, r.Status, problem)
+			}
+			if st.check != nil {
+				st.check(t, variant, r)
+			}
+		}
+	}
+
+	for _, key := range doc.operationKeys() {
+		if !exercised[key] {
+			t.Errorf("%s is documented but no request exercises it", key)
+		}
+	}
+}
+
+// servedSpec fetches the OpenAPI document a server generates and checks that
+// it has the same operations as the reference document
+func servedSpec(t *testing.T, s *server, reference *openAPI) *openAPI {
+	t.Helper()
+
+	r, err := s.do(step{method: http.MethodGet, path: "/openapi.json", anonymous: true}, "")
+	if err != nil {
+		t.Fatalf("%s: /openapi.json: %v", s.name, err)
+	}
+	if r.Status != http.StatusOK {
+		t.Fatalf("%s: /openapi.json: status %d", s.name, r.Status)
+	}
+	generated, err := parseSpec(r.rawBody)
+	if err != nil {
+		t.Fatalf("%s: /openapi.json: %v", s.name, err)
+	}
+
+	want, got := reference.operationKeys(), generated.operationKeys()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("%s: /openapi.json operations\n%s\nwant (%s)\n%s", s.name, strings.Join(got, "\n"), filepath.Base(specFile), strings.Join(want, "\n"))
+	}
+	return generated
+}
+
+// TestRoutesDocumented compares the routes each variant registers with the
+// operations of the OpenAPI document, in both directions
+func TestRoutesDocumented(t *testing.T) {
+	doc, err := loadSpec(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, variant := range variants {
+		routes, err := scanRoutes(filepath.Join("..", variant))
+		if err != nil {
+			t.Fatalf("%s: %v", variant, err)
+		}
+		if len(routes) == 0 {
+			t.Errorf("%s: no routes found", variant)
+			continue
+		}
+
+		registered := map[string]bool{}
+		for _, route := range routes {
+			registered[route] = true
+			if _, ok := doc.operations[route]; !ok {
+				t.Errorf("%s: %s is not documented in %s", variant, route, filepath.Base(specFile))
+			}
+		}
+		for _, key := range doc.operationKeys() {
+			if !registered[key] {
+				t.Errorf("%s: documented %s is not registered", variant, key)
+			}
+		}
+	}
+}
+
+// checkMetrics checks that the requests of the steps before it were counted
+// by route template, that route misses share one label and that the
+// connection pool is reported
+func checkMetrics(t *testing.T, variant string, r *response) {
+	t.Helper()
+
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/api/users/:id",status="200"}`,
+		`http_requests_total{method="GET",route="unmatched",status="404"}`,
+		`http_request_duration_seconds_count{method="POST",route="/api/users",status="201"}`,
+		`http_requests_in_flight 1`,
+		`go_sql_open_connections{db_name="sqlite"}`,
+	} {
+		if !strings.Contains(string(r.rawBody), want) {
+			t.Errorf("%s: /metrics lacks %s", variant, want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+// Package conformance checks that the four Go variants serve the same API.
+//
+// The tests build every server under ../, start it on a temporary SQLite
+// database and send each the same table of requests. They fail whenever a
+// variant answers with a different status code, JSON body or header than the
+// others, and whenever a response or a registered route is not described by
+// the OpenAPI document apidog-collection.json at the repository root or by
+// the document the server generates at /openapi.json. Run them with
+//
+//	go test ./...
+//
+// and skip the ones that start servers with -short.
+package conformance
@@ -0,0 +1,384 @@
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/mail"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// specFile is the OpenAPI document the servers are checked against
+const specFile = "../../apidog-collection.json"
+
+// httpMethods are the operation keys of an OpenAPI path item
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPI is the part of an OpenAPI 3.0 or 3.1 document the contract test
+// uses
+type openAPI struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]*schema `json:"schemas"`
+	} `json:"components"`
+
+	// operations holds the operations of Paths keyed by "METHOD template"
+	operations map[string]*operation
+}
+
+// operation is one documented method of a path
+type operation struct {
+	Responses map[string]*specResponse `json:"responses"`
+}
+
+// specResponse is a documented response of an operation
+type specResponse struct {
+	Headers map[string]json.RawMessage `json:"headers"`
+	Content map[string]struct {
+		Schema *schema `json:"schema"`
+	} `json:"content"`
+}
+
+// schema is the subset of the OpenAPI schema object used by the spec
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Type       schemaType         `json:"type"`
+	Format     string             `json:"format"`
+	Nullable   bool               `json:"nullable"`
+	WriteOnly  bool               `json:"writeOnly"`
+	Required   []string           `json:"required"`
+	Properties map[string]*schema `json:"properties"`
+	Items      *schema            `json:"items"`
+	Enum       []interface{}      `json:"enum"`
+	MinLength  *int               `json:"minLength"`
+	MaxLength  *int               `json:"maxLength"`
+	Minimum    *float64           `json:"minimum"`
+	Maximum    *float64           `json:"maximum"`
+}
+
+// schemaType is the type of a schema. OpenAPI 3.1 allows a list of types,
+// e.g. ["string", "null"], where 3.0 has a single type and nullable.
+type schemaType []string
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaType{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// allows reports whether a value of the JSON type name is allowed; a schema
+// without a type allows any value
+func (t schemaType) allows(name string) bool {
+	if len(t) == 0 {
+		return true
+	}
+	for _, candidate := range t {
+		if candidate == name || candidate == "number" && name == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSpec reads and indexes the OpenAPI document
+func loadSpec(path string) (*openAPI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return doc, nil
+}
+
+// parseSpec indexes an OpenAPI document
+func parseSpec(data []byte) (*openAPI, error) {
+	var doc openAPI
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	doc.operations = map[string]*operation{}
+	for template, item := range doc.Paths {
+		for _, method := range httpMethods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op operation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %v", method, template, err)
+			}
+			doc.operations[strings.ToUpper(method)+" "+template] = &op
+		}
+	}
+	return &doc, nil
+}
+
+// operationKeys returns the sorted "METHOD template" keys of every operation
+func (doc *openAPI) operationKeys() []string {
+	keys := make([]string, 0, len(doc.operations))
+	for key := range doc.operations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// match returns the path template documenting a request path. Literal
+// segments win over parameters, so /api/users/trash is not taken for
+// /api/users/{id}.
+func (doc *openAPI) match(path string) (string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	best, bestParams := "", -1
+	for template := range doc.Paths {
+		parts := strings.Split(strings.Trim(template, "/"), "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+
+		params, ok := 0, true
+		for i, part := range parts {
+			switch {
+			case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+				params++
+			case part != segments[i]:
+				ok = false
+			}
+		}
+		if ok && (bestParams < 0 || params < bestParams) {
+			best, bestParams = template, params
+		}
+	}
+	return best, bestParams >= 0
+}
+
+// resolve follows a local $ref
+func (doc *openAPI) resolve(s *schema) (*schema, error) {
+	for s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		target, ok := doc.Components.Schemas[name]
+		if !ok || name == s.Ref {
+			return nil, fmt.Errorf("unresolved $ref %q", s.Ref)
+		}
+		s = target
+	}
+	return s, nil
+}
+
+// checkResponse validates a response against the operation documenting it
+// and returns every violation found
+func (doc *openAPI) checkResponse(key string, r *response, body []byte) []string {
+	op, ok := doc.operations[key]
+	if !ok {
+		return []string{"undocumented endpoint " + key}
+	}
+
+	documented, ok := op.Responses[strconv.Itoa(r.Status)]
+	if !ok {
+		documented, ok = op.Responses["default"]
+	}
+	if !ok {
+		return []string{fmt.Sprintf("undocumented status %d", r.Status)}
+	}
+
+	var problems []string
+	for name := range documented.Headers {
+		if r.raw.Get(name) == "" {
+			problems = append(problems, "missing header "+name)
+		}
+	}
+
+	if len(documented.Content) == 0 {
+		if len(strings.TrimSpace(string(body))) > 0 {
+			problems = append(problems, "body sent for a response documented without content")
+		}
+		return problems
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.raw.Get("Content-Type"))
+	if err != nil {
+		return append(problems, fmt.Sprintf("Content-Type %q: %v", r.raw.Get("Content-Type"), err))
+	}
+	content, ok := documented.Content[mediaType]
+	if !ok {
+		content, ok = documented.Content["*/*"]
+	}
+	if !ok {
+		return append(problems, "undocumented Content-Type "+mediaType)
+	}
+	if !isJSON(mediaType) {
+		// Only JSON bodies are validated against their schema
+		return problems
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return append(problems, fmt.Sprintf("body is not JSON: %v", err))
+	}
+	return append(problems, doc.validate(content.Schema, value, "body")...)
+}
+
+// checkProblem validates a response that no operation documents, such as a
+// route miss, against the Problem schema
+func (doc *openAPI) checkProblem(r *response, body []byte) []string {
+	mediaType, _, _ := mime.ParseMediaType(r.raw.Get("Content-Type"))
+	if mediaType != "application/problem+json" {
+		return []string{"Content-Type " + mediaType + ", want application/problem+json"}
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []string{fmt.Sprintf("body is not JSON: %v", err)}
+	}
+	return doc.validate(&schema{Ref: "#/components/schemas/Problem"}, value, "body")
+}
+
+// validate checks a decoded JSON value against a schema. Object properties
+// missing from the schema are reported as undocumented fields.
+func (doc *openAPI) validate(s *schema, value interface{}, at string) []string {
+	if s == nil {
+		return nil
+	}
+	s, err := doc.resolve(s)
+	if err != nil {
+		return []string{at + ": " + err.Error()}
+	}
+
+	if value == nil {
+		if s.Nullable || s.Type.allows("null") {
+			return nil
+		}
+		return []string{at + ": null is not allowed"}
+	}
+
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, at+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		fail("%v is not one of %v", value, s.Enum)
+	}
+
+	kind := jsonType(value)
+	if kind == "number" && value.(float64) == float64(int64(value.(float64))) {
+		kind = "integer"
+	}
+	if !s.Type.allows(kind) {
+		fail("expected %s, got %s", strings.Join(s.Type, " or "), jsonType(value))
+		return problems
+	}
+
+	switch kind {
+	case "object":
+		object := value.(map[string]interface{})
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				fail("missing required field %q", name)
+			}
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				if len(s.Properties) > 0 {
+					fail("undocumented field %q", name)
+				}
+				continue
+			}
+			if resolved, err := doc.resolve(property); err == nil && resolved.WriteOnly {
+				fail("write-only field %q in a response", name)
+				continue
+			}
+			problems = append(problems, doc.validate(property, object[name], at+"."+name)...)
+		}
+	case "array":
+		items := value.([]interface{})
+		for i, item := range items {
+			problems = append(problems, doc.validate(s.Items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		str := value.(string)
+		if s.MinLength != nil && len([]rune(str)) < *s.MinLength {
+			fail("shorter than %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && len([]rune(str)) > *s.MaxLength {
+			fail("longer than %d characters", *s.MaxLength)
+		}
+		if err := checkFormat(s.Format, str); err != nil {
+			fail("%v", err)
+		}
+	case "integer", "number":
+		n := value.(float64)
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("%v is less than %v", n, *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("%v is greater than %v", n, *s.Maximum)
+		}
+	}
+	return problems
+}
+
+// isJSON reports whether a media type carries JSON, e.g. application/json or
+// application/problem+json
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// checkFormat validates the string formats used by the spec
+func checkFormat(format, value string) error {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+			return fmt.Errorf("%q is not a date-time", value)
+		}
+	case "email":
+		if _, err := mail.ParseAddress(value); err != nil {
+			return fmt.Errorf("%q is not an email address", value)
+		}
+	}
+	return nil
+}
+
+// containsValue reports whether values contains v
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
@@ -0,0 +1,122 @@
+package conformance
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// routeMethods maps the route registration methods of gin, echo and fiber
+// to HTTP methods
+var routeMethods = map[string]string{
+	"GET": "GET", "POST": "POST", "PUT": "PUT", "PATCH": "PATCH", "DELETE": "DELETE",
+	"Get": "GET", "Post": "POST", "Put": "PUT", "Patch": "PATCH", "Delete": "DELETE",
+}
+
+// pathParam matches a gin/echo/fiber path parameter such as :id
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// scanRoutes lists the routes a variant registers as sorted "METHOD template"
+// keys, with path parameters written the OpenAPI way ({id}). It reads the
+// source instead of running the server so routes the request table never
+// reaches are found too.
+func scanRoutes(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+
+			// gin, echo, fiber: router.GET("/path", ...)
+			if method, ok := routeMethods[sel.Sel.Name]; ok {
+				if path, ok := routePath(call); ok {
+					found[method+" "+path] = true
+				}
+				return true
+			}
+
+			// gorilla/mux: router.Handle("/path", ...).Methods("GET")
+			if sel.Sel.Name == "Methods" {
+				inner, ok := sel.X.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				innerSel, ok := inner.Fun.(*ast.SelectorExpr)
+				if !ok || (innerSel.Sel.Name != "Handle" && innerSel.Sel.Name != "HandleFunc") {
+					return true
+				}
+				path, ok := routePath(inner)
+				if !ok {
+					return true
+				}
+				for _, arg := range call.Args {
+					if method, ok := stringLiteral(arg); ok {
+						found[strings.ToUpper(method)+" "+path] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	routes := make([]string, 0, len(found))
+	for route := range found {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	return routes, nil
+}
+
+// routePath returns the path literal registered by a routing call
+func routePath(call *ast.CallExpr) (string, bool) {
+	if len(call.Args) == 0 {
+		return "", false
+	}
+	path, ok := stringLiteral(call.Args[0])
+	if !ok || !strings.HasPrefix(path, "/") {
+		return "", false
+	}
+	return pathParam.ReplaceAllString(path, "{$1}"), true
+}
+
+// stringLiteral returns the value of a string literal expression
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
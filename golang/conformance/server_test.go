@@ -0,0 +1,209 @@
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// variants are the server directories under test, relative to this module
+var variants = []string{"gin-gorm", "echo-gorm", "fiber-gorm", "gorilla-gorm"}
+
+// startTimeout bounds how long a server may take to answer its first request
+const startTimeout = 30 * time.Second
+
+// stopTimeout bounds how long a server may take to exit after SIGTERM
+const stopTimeout = 10 * time.Second
+
+// server is a running variant
+type server struct {
+	name string
+	bin  string
+	dir  string
+	env  []string
+	base string
+	out  *syncBuffer
+}
+
+// syncBuffer collects the output of a server process
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// buildServers compiles every variant in parallel and returns the binaries
+// by variant name
+func buildServers(t *testing.T) map[string]string {
+	t.Helper()
+
+	goBin := filepath.Join(runtime.GOROOT(), "bin", "go")
+	binDir := t.TempDir()
+
+	bins := make(map[string]string, len(variants))
+	errs := make([]error, len(variants))
+	var wg sync.WaitGroup
+	for i, variant := range variants {
+		bin := filepath.Join(binDir, variant)
+		bins[variant] = bin
+
+		wg.Add(1)
+		go func(i int, variant, bin string) {
+			defer wg.Done()
+			cmd := exec.Command(goBin, "build", "-o", bin, ".")
+			cmd.Dir = filepath.Join("..", variant)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				errs[i] = fmt.Errorf("build %s: %v\n%s", variant, err, out)
+			}
+		}(i, variant, bin)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bins
+}
+
+// startServer runs a variant on a fresh SQLite database and waits until it
+// answers. The server is stopped when the test ends; its output is logged
+// if the test failed.
+func startServer(t *testing.T, name, bin string) *server {
+	t.Helper()
+
+	port, err := freePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run in an empty directory so no .env file is picked up
+	dir := t.TempDir()
+	s := &server{
+		name: name,
+		bin:  bin,
+		dir:  dir,
+		env: append(os.Environ(),
+			"DB_DRIVER=sqlite",
+			"DB_NAME="+filepath.Join(dir, "conformance.db"),
+			"JWT_SECRET=conformance-secret-0123456789abcdef",
+			"JWT_ALGORITHM=HS256",
+			"MIGRATE_ON_START=true",
+			"REQUIRE_IF_MATCH=false",
+			"MAX_BODY_SIZE=4096",
+			"GIN_MODE=release",
+			"LOG_LEVEL=debug",
+			"OTEL_TRACES_EXPORTER=console",
+			"TRACES_FILE="+filepath.Join(dir, "traces.json"),
+			"PORT="+port,
+		),
+		base: "http://127.0.0.1:" + port,
+		out:  &syncBuffer{},
+	}
+
+	cmd := exec.Command(bin)
+	cmd.Dir = dir
+	cmd.Env = s.env
+	cmd.Stdout = s.out
+	cmd.Stderr = s.out
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		// Every server shuts down gracefully on SIGTERM: it exits cleanly
+		// after closing the database and flushing its spans
+		exited := make(chan error, 1)
+		cmd.Process.Signal(syscall.SIGTERM)
+		go func() { exited <- cmd.Wait() }()
+		select {
+		case err := <-exited:
+			if err != nil {
+				t.Errorf("%s: exit after SIGTERM: %v", name, err)
+			} else if !strings.Contains(s.out.String(), "Database connection closed") {
+				t.Errorf("%s: exited without closing the database", name)
+			} else if !t.Failed() {
+				// Spans are flushed on shutdown, once every step ran
+				checkTraces(t, name, filepath.Join(dir, "traces.json"))
+				checkLogs(t, name, s.out.String())
+			}
+		case <-time.After(stopTimeout):
+			t.Errorf("%s: still running %s after SIGTERM", name, stopTimeout)
+			cmd.Process.Kill()
+			<-exited
+		}
+		if t.Failed() {
+			t.Logf("%s output:\n%s", name, tail(s.out.String(), 40))
+		}
+	})
+
+	deadline := time.Now().Add(startTimeout)
+	for {
+		resp, err := http.Get(s.base + "/")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return s
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s did not start within %s:\n%s", name, startTimeout, tail(s.out.String(), 40))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// command runs a maintenance subcommand (e.g. grant-role) of the server
+// against its database
+func (s *server) command(t *testing.T, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command(s.bin, args...)
+	cmd.Dir = s.dir
+	cmd.Env = s.env
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%s %s: %v\n%s", s.name, strings.Join(args, " "), err, tail(string(out), 20))
+	}
+}
+
+// freePort asks the kernel for an unused TCP port
+func freePort() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	return port, err
+}
+
+// tail returns the last n lines of s
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,57 +1,25 @@
 package config
 
 import (
-	"fmt"
+	"common/database"
 	"log"
-	"os"
-	"time"
+	"log/slog"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
-// InitDatabase initializes the database connection
-func InitDatabase() error {
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbUser := os.Getenv("DB_USER")
-	dbPassword := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
-
-	if dbPort == "" {
-		dbPort = "3306"
-	}
-
-	// DSN (Data Source Name) for MariaDB/MySQL
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
-
+// InitDatabase initializes the database connection for the driver
+// selected by DB_DRIVER (mysql, postgres or sqlite)
+func InitDatabase(cfg database.Config) error {
 	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-		NowFunc: func() time.Time {
-			return time.Now().Local()
-		},
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to connect to database: %v", err)
-	}
-
-	// Connection pool settings
-	sqlDB, err := DB.DB()
+	DB, err = database.Open(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to get database instance: %v", err)
+		return err
 	}
 
-	sqlDB.SetMaxIdleConns(0)
-	sqlDB.SetMaxOpenConns(5)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	log.Println("Database connection established successfully")
+	log.Printf("Database connection established successfully (%s)", cfg.Driver)
 	return nil
 }
 
@@ -59,3 +27,12 @@ func InitDatabase() error {
 func GetDB() *gorm.DB {
 	return DB
 }
+
+// CloseDatabase closes the connection pool
+func CloseDatabase() {
+	if err := database.Close(DB); err != nil {
+		slog.Error("Failed to close database", "driver", DB.Dialector.Name(), "error", err)
+		return
+	}
+	log.Println("Database connection closed")
+}
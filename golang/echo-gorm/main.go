@@ -1,42 +1,134 @@
 package main
 
 import (
+	"common/auth"
+	"common/cli"
+	"common/etag"
+	"common/health"
+	"common/lifecycle"
+	"common/logging"
+	"common/metrics"
+	"common/migrations"
+	"common/password"
+	"common/rbac"
+	"common/repository"
+	"common/service"
+	"common/settings"
+	"common/tracing"
+	"common/trash"
+	"common/validation"
 	"echo-gorm/config"
-	"echo-gorm/models"
+	appmiddleware "echo-gorm/middleware"
 	"echo-gorm/routes"
 	"fmt"
 	"log"
 	"os"
 
-	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found, using system environment variables")
+	// Load the configuration: defaults, config file, .env and environment
+	cfg, err := settings.Load()
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+
+	// Write JSON logs through slog
+	logging.Init(cfg.Logging)
+
+	// Print the configuration (config print) instead of the server
+	if handled, err := cli.RunConfig(os.Args[1:], cfg); handled {
+		if err != nil {
+			log.Fatal("Command failed:", err)
+		}
+		return
 	}
 
 	// Initialize database connection
-	if err := config.InitDatabase(); err != nil {
+	if err := config.InitDatabase(cfg.Database); err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
-	// Auto migrate the schema
-	if err := config.DB.AutoMigrate(&models.User{}); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// Apply schema migrations, unless the command manages them itself
+	if !cli.ManagesSchema(os.Args[1:]) {
+		if err := migrations.ApplyOnStart(config.DB, cfg.MigrateOnStart); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		log.Println("Database synchronized")
+
+		// Make sure the roles of the access policy exist
+		if err := rbac.SeedRoles(config.DB); err != nil {
+			log.Fatal("Failed to seed roles:", err)
+		}
+	}
+
+	// Configure password hashing, conditional request checks (If-Match) and
+	// the request body size limit
+	password.SetDefault(password.NewHasher(cfg.Password))
+	etag.SetRequireIfMatch(cfg.RequireIfMatch)
+	validation.SetMaxBodySize(cfg.MaxBodySize)
+
+	// Run a maintenance command (e.g. hash-passwords) instead of the server
+	if handled, err := cli.Run(os.Args[1:], config.DB); handled {
+		if err != nil {
+			log.Fatal("Command failed:", err)
+		}
+		return
+	}
+
+	// Configure access token signing
+	tokens, err := auth.NewTokenManager(cfg.Auth)
+	if err != nil {
+		log.Fatal("Failed to configure authentication:", err)
+	}
+
+	// Fail fast with 503 while the database is failing instead of queueing
+	// for the connection pool, on user and session queries alike
+	dbBreaker := repository.NewDatabaseBreaker(cfg.Breaker)
+	sessions := auth.NewSessionManager(config.DB, tokens, dbBreaker)
+	repo := repository.NewBreakerUserRepository(repository.NewGormUserRepository(config.DB), dbBreaker)
+	users := service.NewUserService(repo, sessions)
+
+	// Permanently delete users that stayed in the trash past the retention
+	stopPurger := trash.StartPurger(config.DB, cfg.Trash)
+
+	// Check the database, migrations and connection pool on readiness probes
+	checker, err := health.NewChecker(config.DB, cfg.Health)
+	if err != nil {
+		log.Fatal("Failed to configure readiness checks:", err)
+	}
+
+	// Collect request and connection pool metrics for GET /metrics
+	appMetrics, err := metrics.New(config.DB)
+	if err != nil {
+		log.Fatal("Failed to configure metrics:", err)
+	}
+
+	// Trace requests and queries (OTEL_TRACES_EXPORTER)
+	stopTracing, err := tracing.Init(cfg.Tracing, "echo-gorm")
+	if err != nil {
+		log.Fatal("Failed to configure tracing:", err)
 	}
-	log.Println("Database synchronized")
 
 	// Create Echo instance
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+
+	// Answer unknown routes, unsupported methods and panics with problems
+	e.HTTPErrorHandler = appmiddleware.ErrorHandler
 
 	// Middleware
-	e.Use(middleware.Logger())
+	e.Use(appmiddleware.RequestID())
+	e.Use(appmiddleware.Metrics(appMetrics))
+	e.Use(appmiddleware.Tracing())
+	e.Use(appmiddleware.AccessLog())
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		ExposeHeaders: []string{"ETag", "Link", "Accept-Patch", "X-Request-ID"},
+	}))
 
 	// Health check endpoint
 	e.GET("/", func(c echo.Context) error {
@@ -46,19 +138,31 @@ func main() {
 		})
 	})
 
-	// Setup user routes
-	routes.SetupUserRoutes(e)
+	// Setup liveness and readiness probes
+	routes.SetupHealthRoutes(e, checker)
+
+	// Expose Prometheus metrics
+	routes.SetupMetricsRoutes(e, appMetrics)
 
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3001"
+	// Setup auth, user and role routes
+	routes.SetupAuthRoutes(e, users)
+	routes.SetupUserRoutes(e, users, tokens)
+	routes.SetupRoleRoutes(e, users, tokens)
+
+	// Serve the OpenAPI document of the routes above and the Swagger UI
+	if err := routes.SetupDocsRoutes(e); err != nil {
+		log.Fatal("Failed to build the OpenAPI document:", err)
 	}
 
-	// Start server
-	addr := fmt.Sprintf("0.0.0.0:%s", port)
-	log.Printf("Server is running on port %s", port)
-	if err := e.Start(addr); err != nil {
+	// Start server. On SIGINT/SIGTERM it drains in-flight requests, then the
+	// purger stops and the connection pool closes.
+	addr := fmt.Sprintf("0.0.0.0:%d", cfg.Port)
+	log.Printf("Server is running on port %d", cfg.Port)
+	server := lifecycle.Server{
+		Serve:    func() error { return e.Start(addr) },
+		Shutdown: e.Shutdown,
+	}
+	if err := lifecycle.Run(cfg.Shutdown, server, stopPurger, config.CloseDatabase, stopTracing); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"common/logging"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AccessLog logs every request with its status and latency. It runs after
+// RequestID, so the line carries the request ID.
+func AccessLog() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			if err := next(c); err != nil {
+				// Write the error response now, so its status is logged
+				c.Error(err)
+			}
+			req := c.Request()
+			logging.Access(req.Context(), logging.Request{
+				Method:    req.Method,
+				Path:      req.URL.Path,
+				Route:     c.Path(),
+				Status:    c.Response().Status,
+				Bytes:     int(c.Response().Size),
+				Duration:  time.Since(start),
+				RemoteIP:  c.RealIP(),
+				UserAgent: req.UserAgent(),
+			})
+			return nil
+		}
+	}
+}
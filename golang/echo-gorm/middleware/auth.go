@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"common/auth"
+	"common/problem"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Authenticate validates the bearer access token and stores the
+// authenticated principal in the request context
+func Authenticate(tokens *auth.TokenManager) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, ok := auth.BearerToken(c.Request().Header.Get("Authorization"))
+			if !ok {
+				return unauthorized(c, problem.CodeUnauthorized, "Missing bearer token")
+			}
+
+			principal, err := tokens.Parse(token)
+			if err != nil {
+				return unauthorized(c, problem.CodeInvalidToken, err.Error())
+			}
+
+			c.SetRequest(c.Request().WithContext(auth.WithPrincipal(c.Request().Context(), principal)))
+			return next(c)
+		}
+	}
+}
+
+func unauthorized(c echo.Context, code, message string) error {
+	c.Response().Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	return SendProblem(c, problem.New(http.StatusUnauthorized, code, message))
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"common/auth"
+	"common/problem"
+	"common/rbac"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Authorize checks the authenticated principal against the shared access
+// policy. The :id route parameter, when present, is the user the request
+// targets. Must be used after Authenticate.
+func Authorize(perm rbac.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, _ := auth.PrincipalFrom(c.Request().Context())
+
+			if !rbac.Can(principal, perm, rbac.OwnerID(c.Param("id"))) {
+				return SendProblem(c, problem.New(http.StatusForbidden, problem.CodeForbidden, "You do not have permission to perform this action"))
+			}
+
+			return next(c)
+		}
+	}
+}
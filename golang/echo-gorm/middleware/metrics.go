@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"common/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Metrics records the count and latency of every request by route template
+// and status code
+func Metrics(m *metrics.Metrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			done := m.Begin()
+			if err := next(c); err != nil {
+				// Write the error response now, so its status is recorded
+				c.Error(err)
+			}
+			done(c.Request().Method, c.Path(), c.Response().Status)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"common/problem"
+	"common/requestid"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SendProblem responds with an RFC 7807 problem
+func SendProblem(c echo.Context, p *problem.Problem) error {
+	req := c.Request()
+	p.ForRequest(req.Method, req.URL.Path, requestid.From(req.Context()))
+	c.Response().Header().Set(echo.HeaderContentType, problem.MediaType)
+	if p.RetryAfter > 0 {
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(p.RetryAfter))
+	}
+	return c.JSON(p.Status, p)
+}
+
+// ErrorHandler is the HTTP error handler of the server. It answers the
+// errors echo produces on its own, such as unknown routes, unsupported
+// methods and recovered panics, with problems.
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	p := problem.Internal(err)
+	var he *echo.HTTPError
+	if errors.As(err, &he) && he.Code < http.StatusInternalServerError {
+		p = problem.FromStatus(he.Code)
+	}
+	if err := SendProblem(c, p); err != nil {
+		c.Logger().Error(err)
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"common/requestid"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestID assigns every request an ID, the client's X-Request-ID when it
+// sent a usable one, stores it in the request context and echoes it in the
+// response
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id := requestid.FromHeader(c.Request().Header.Get(requestid.Header))
+			c.Response().Header().Set(requestid.Header, id)
+			c.SetRequest(c.Request().WithContext(requestid.WithContext(c.Request().Context(), id)))
+			return next(c)
+		}
+	}
+}
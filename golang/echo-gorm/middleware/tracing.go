@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"common/tracing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Tracing runs every request in a server span that continues the trace of
+// its traceparent header. Handlers reach the span through the request
+// context, so the queries they run become its children.
+func Tracing() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			ctx, span := tracing.StartServer(r.Context(), propagation.HeaderCarrier(r.Header), r.Method, r.URL.Path)
+			c.SetRequest(r.WithContext(ctx))
+			if err := next(c); err != nil {
+				// Write the error response now, so its status is recorded
+				c.Error(err)
+			}
+			tracing.EndServer(span, r.Method, c.Path(), c.Response().Status)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"common/dto"
+	"common/service"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SetupAuthRoutes sets up authentication routes
+func SetupAuthRoutes(e *echo.Echo, users *service.UserService) {
+	// LOGIN - 액세스/리프레시 토큰 발급
+	e.POST("/api/auth/login", login(users))
+
+	// REFRESH - 리프레시 토큰 교체 및 액세스 토큰 재발급
+	e.POST("/api/auth/refresh", refresh(users))
+
+	// LOGOUT - 리프레시 토큰 폐기
+	e.POST("/api/auth/logout", logout(users))
+}
+
+// login authenticates a user by email or username and starts a new session
+func login(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req dto.LoginRequest
+
+		if err := bindJSON(c, &req); err != nil {
+			return sendError(c, err)
+		}
+
+		pair, user, err := users.Login(c.Request().Context(), req)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    dto.NewTokenResponse(pair, user),
+		})
+	}
+}
+
+// refresh rotates a refresh token and issues a new token pair
+func refresh(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req dto.RefreshRequest
+
+		if err := bindJSON(c, &req); err != nil {
+			return sendError(c, err)
+		}
+
+		pair, user, err := users.Refresh(c.Request().Context(), req)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    dto.NewTokenResponse(pair, user),
+		})
+	}
+}
+
+// logout revokes the session the refresh token belongs to
+func logout(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req dto.RefreshRequest
+
+		if err := bindJSON(c, &req); err != nil {
+			return sendError(c, err)
+		}
+
+		if err := users.Logout(c.Request().Context(), req); err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Logged out successfully",
+		})
+	}
+}
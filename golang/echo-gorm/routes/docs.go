@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"common/openapi"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SetupDocsRoutes serves the OpenAPI document of every route registered so
+// far and the Swagger UI. Call it after all other routes are set up.
+func SetupDocsRoutes(e *echo.Echo) error {
+	var document []byte
+
+	// DOCS - OpenAPI 문서
+	e.GET("/openapi.json", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, document)
+	})
+
+	// DOCS - Swagger UI 페이지 및 정적 파일
+	docs := echo.WrapHandler(openapi.DocsHandler())
+	e.GET("/docs", docs)
+	e.GET("/docs/:file", docs)
+
+	registered := e.Routes()
+	routes := make([]openapi.Route, 0, len(registered))
+	for _, route := range registered {
+		routes = append(routes, openapi.Route{Method: route.Method, Path: route.Path})
+	}
+
+	var err error
+	document, err = openapi.Build(routes)
+	return err
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"common/health"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SetupHealthRoutes sets up the liveness and readiness probes
+func SetupHealthRoutes(e *echo.Echo, checker *health.Checker) {
+	// HEALTH - 프로세스 생존 확인 (liveness)
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, checker.Live())
+	})
+
+	// HEALTH - 트래픽 수신 가능 여부 확인 (readiness: DB, 마이그레이션, 연결 풀, 종료 중 여부)
+	e.GET("/readyz", func(c echo.Context) error {
+		report := checker.Ready(c.Request().Context())
+		return c.JSON(readyStatus(report), report)
+	})
+}
+
+// readyStatus is 200 for a ready instance and 503 otherwise
+func readyStatus(report health.Report) int {
+	if report.OK() {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"common/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SetupMetricsRoutes serves the Prometheus metrics
+func SetupMetricsRoutes(e *echo.Echo, m *metrics.Metrics) {
+	// METRICS - Prometheus 수집 엔드포인트
+	e.GET("/metrics", echo.WrapHandler(m.Handler()))
+}
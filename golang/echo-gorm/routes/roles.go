@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"common/auth"
+	"common/dto"
+	"common/rbac"
+	"common/service"
+	"echo-gorm/middleware"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SetupRoleRoutes sets up role listing and assignment routes
+func SetupRoleRoutes(e *echo.Echo, users *service.UserService, tokens *auth.TokenManager) {
+	authenticate := middleware.Authenticate(tokens)
+	authorize := middleware.Authorize
+
+	// READ - 역할 및 권한 목록 조회
+	e.GET("/api/roles", getRoles, authenticate, authorize(rbac.RolesRead))
+
+	// READ - 사용자 역할 조회
+	e.GET("/api/users/:id/roles", getUserRoles(users), authenticate, authorize(rbac.RolesRead))
+
+	// UPDATE - 사용자 역할 지정 (관리자 전용)
+	e.PUT("/api/users/:id/roles", setUserRoles(users), authenticate, authorize(rbac.RolesAssign))
+}
+
+// getRoles lists the roles of the access policy with their permissions
+func getRoles(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    dto.NewRoleResponses(),
+	})
+}
+
+// getUserRoles lists the roles held by a user
+func getUserRoles(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := users.Get(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    dto.NewUserRolesResponse(user),
+		})
+	}
+}
+
+// setUserRoles replaces the roles held by a user
+func setUserRoles(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req dto.UserRolesRequest
+		if err := bindJSON(c, &req); err != nil {
+			return sendError(c, err)
+		}
+
+		user, err := users.SetRoles(c.Request().Context(), c.Param("id"), req)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    dto.NewUserRolesResponse(user),
+		})
+	}
+}
@@ -1,171 +1,229 @@
 package routes
 
 import (
-	"echo-gorm/config"
-	"echo-gorm/models"
+	"common/auth"
+	"common/dto"
+	"common/etag"
+	"common/models"
+	"common/patch"
+	"common/query"
+	"common/rbac"
+	"common/repository"
+	"common/service"
+	"common/validation"
+	"echo-gorm/middleware"
+	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/labstack/echo/v4"
 )
 
 // SetupUserRoutes sets up all user-related routes
-func SetupUserRoutes(e *echo.Echo) {
-	// CREATE - 사용자 생성
-	e.POST("/api/users", createUser)
+func SetupUserRoutes(e *echo.Echo, users *service.UserService, tokens *auth.TokenManager) {
+	authenticate := middleware.Authenticate(tokens)
+	authorize := middleware.Authorize
+
+	// CREATE - 사용자 생성 (회원가입, 인증 불필요)
+	e.POST("/api/users", createUser(users))
 
 	// READ - 모든 사용자 조회
-	e.GET("/api/users", getUsers)
+	e.GET("/api/users", getUsers(users), authenticate, authorize(rbac.UsersList))
+
+	// READ - 삭제된 사용자(휴지통) 조회 (관리자 전용)
+	e.GET("/api/users/trash", getDeletedUsers(users), authenticate, authorize(rbac.UsersTrash))
+
+	// UPDATE - 삭제된 사용자 복구 (관리자 전용)
+	e.POST("/api/users/:id/restore", restoreUser(users), authenticate, authorize(rbac.UsersTrash))
 
 	// READ - 특정 사용자 조회
-	e.GET("/api/users/:id", getUser)
+	e.GET("/api/users/:id", getUser(users), authenticate, authorize(rbac.UsersRead))
+
+	// UPDATE - 사용자 수정 (전체 교체)
+	e.PUT("/api/users/:id", updateUser(users), authenticate, authorize(rbac.UsersUpdate))
 
-	// UPDATE - 사용자 수정
-	e.PUT("/api/users/:id", updateUser)
+	// UPDATE - 사용자 부분 수정 (JSON Merge Patch / JSON Patch)
+	e.PATCH("/api/users/:id", patchUser(users), authenticate, authorize(rbac.UsersUpdate))
 
 	// DELETE - 사용자 삭제
-	e.DELETE("/api/users/:id", deleteUser)
-}
+	e.DELETE("/api/users/:id", deleteUser(users), authenticate, authorize(rbac.UsersDelete))
 
-// createUser creates a new user
-func createUser(c echo.Context) error {
-	var user models.User
+	// DELETE - 사용자의 모든 세션(리프레시 토큰) 폐기 (관리자 전용)
+	e.DELETE("/api/users/:id/sessions", revokeSessions(users), authenticate, authorize(rbac.SessionsRevoke))
+}
 
-	if err := c.Bind(&user); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
-	}
+// sendError responds with the problem a service error maps to
+func sendError(c echo.Context, err error) error {
+	return middleware.SendProblem(c, service.Problem(err))
+}
 
-	if err := config.DB.Create(&user).Error; err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
+// bindJSON reads the request body and strictly decodes it into v
+func bindJSON(c echo.Context, v interface{}) error {
+	body, err := validation.ReadBody(c.Request().Body)
+	if err != nil {
+		return err
 	}
+	return validation.Decode(body, v)
+}
 
-	return c.JSON(http.StatusCreated, map[string]interface{}{
+// sendUser responds with a user and its ETag
+func sendUser(c echo.Context, status int, user *models.User) error {
+	c.Response().Header().Set("ETag", etag.For(user.ID, user.Version))
+	return c.JSON(status, map[string]interface{}{
 		"success": true,
-		"data":    user,
+		"data":    dto.NewUserResponse(user),
 	})
 }
 
-// getUsers retrieves all users
-func getUsers(c echo.Context) error {
-	var users []models.User
+// createUser creates a new user
+func createUser(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req dto.CreateUserRequest
 
-	if err := config.DB.Order("id ASC").Find(&users).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
+		if err := bindJSON(c, &req); err != nil {
+			return sendError(c, err)
+		}
+
+		user, err := users.Create(c.Request().Context(), req)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return sendUser(c, http.StatusCreated, user)
 	}
+}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    users,
-	})
+// getUsers retrieves all users
+func getUsers(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return listUsers(c, users, repository.Active, query.Users)
+	}
 }
 
-// getUser retrieves a specific user by ID
-func getUser(c echo.Context) error {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Invalid user ID",
-		})
+// getDeletedUsers lists soft-deleted users, most recently deleted first
+func getDeletedUsers(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return listUsers(c, users, repository.Trashed, query.DeletedUsers)
 	}
+}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]interface{}{
-			"success": false,
-			"error":   "User not found",
-		})
+// listUsers responds with one page of the users of a scope
+func listUsers(c echo.Context, users *service.UserService, scope repository.Scope, spec *query.Spec) error {
+	page, err := users.List(c.Request().Context(), scope, spec, c.Request().URL.Path, c.Request().URL.RawQuery)
+	if err != nil {
+		return sendError(c, err)
 	}
 
+	c.Response().Header().Set("Link", page.Link)
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"success": true,
-		"data":    user,
+		"data":    dto.NewUserResponses(page.Users),
+		"meta":    page.Meta,
 	})
 }
 
-// updateUser updates an existing user
-func updateUser(c echo.Context) error {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Invalid user ID",
-		})
+// getUser retrieves a specific user by ID
+func getUser(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := users.Get(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		tag := etag.For(user.ID, user.Version)
+		if etag.NotModified(c.Request().Header.Get("If-None-Match"), tag) {
+			c.Response().Header().Set("ETag", tag)
+			return c.NoContent(http.StatusNotModified)
+		}
+
+		return sendUser(c, http.StatusOK, user)
 	}
+}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]interface{}{
-			"success": false,
-			"error":   "User not found",
-		})
-	}
+// updateUser replaces a user's email, username and password
+func updateUser(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var updateData dto.UpdateUserRequest
+		if err := bindJSON(c, &updateData); err != nil {
+			return sendError(c, err)
+		}
 
-	var updateData models.User
-	if err := c.Bind(&updateData); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
-	}
+		user, err := users.Replace(c.Request().Context(), c.Param("id"), c.Request().Header.Get("If-Match"), updateData)
+		if err != nil {
+			return sendError(c, err)
+		}
 
-	// Update user fields
-	if err := config.DB.Model(&user).Updates(map[string]interface{}{
-		"email":    updateData.Email,
-		"username": updateData.Username,
-		"password": updateData.Password,
-	}).Error; err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
+		return sendUser(c, http.StatusOK, user)
 	}
+}
 
-	// Fetch updated user
-	config.DB.First(&user, id)
-
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    user,
-	})
+// patchUser updates only the fields supplied by a merge patch or JSON patch
+func patchUser(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		body, err := validation.ReadBody(c.Request().Body)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		header := c.Request().Header
+		user, err := users.Patch(c.Request().Context(), c.Param("id"), header.Get("If-Match"), header.Get("Content-Type"), body)
+		if err != nil {
+			if errors.Is(err, patch.ErrUnsupportedMediaType) {
+				c.Response().Header().Set("Accept-Patch", patch.AcceptPatch)
+			}
+			return sendError(c, err)
+		}
+
+		return sendUser(c, http.StatusOK, user)
+	}
 }
 
-// deleteUser deletes a user
-func deleteUser(c echo.Context) error {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Invalid user ID",
+// deleteUser moves a user to the trash, or removes it permanently with ?hard=true
+func deleteUser(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		hard, err := service.ParseHard(c.QueryParam("hard"))
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := users.Delete(c.Request().Context(), c.Param("id"), c.Request().Header.Get("If-Match"), hard); err != nil {
+			return sendError(c, err)
+		}
+
+		message := "User deleted successfully"
+		if hard {
+			message = "User permanently deleted"
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": message,
 		})
 	}
+}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]interface{}{
-			"success": false,
-			"error":   "User not found",
-		})
+// restoreUser moves a soft-deleted user out of the trash
+func restoreUser(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := users.Restore(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return sendUser(c, http.StatusOK, user)
 	}
+}
 
-	if err := config.DB.Delete(&user).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
+// revokeSessions revokes every refresh token of a user (admin only)
+func revokeSessions(users *service.UserService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		revoked, err := users.RevokeSessions(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Sessions revoked successfully",
+			"data":    map[string]interface{}{"revoked": revoked},
 		})
 	}
-
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"message": "User deleted successfully",
-	})
 }
@@ -1,8 +1,24 @@
 package main
 
 import (
+	"common/auth"
+	"common/cli"
+	"common/etag"
+	"common/health"
+	"common/lifecycle"
+	"common/logging"
+	"common/metrics"
+	"common/migrations"
+	"common/password"
+	"common/rbac"
+	"common/repository"
+	"common/service"
+	"common/settings"
+	"common/tracing"
+	"common/trash"
+	"common/validation"
 	"fiber-gorm/config"
-	"fiber-gorm/models"
+	"fiber-gorm/middleware"
 	"fiber-gorm/routes"
 	"fmt"
 	"log"
@@ -10,40 +26,114 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found, using system environment variables")
+	// Load the configuration: defaults, config file, .env and environment
+	cfg, err := settings.Load()
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+
+	// Write JSON logs through slog
+	logging.Init(cfg.Logging)
+
+	// Print the configuration (config print) instead of the server
+	if handled, err := cli.RunConfig(os.Args[1:], cfg); handled {
+		if err != nil {
+			log.Fatal("Command failed:", err)
+		}
+		return
 	}
 
 	// Initialize database connection
-	if err := config.InitDatabase(); err != nil {
+	if err := config.InitDatabase(cfg.Database); err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
-	// Auto migrate the schema
-	if err := config.DB.AutoMigrate(&models.User{}); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// Apply schema migrations, unless the command manages them itself
+	if !cli.ManagesSchema(os.Args[1:]) {
+		if err := migrations.ApplyOnStart(config.DB, cfg.MigrateOnStart); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		log.Println("Database synchronized")
+
+		// Make sure the roles of the access policy exist
+		if err := rbac.SeedRoles(config.DB); err != nil {
+			log.Fatal("Failed to seed roles:", err)
+		}
+	}
+
+	// Configure password hashing, conditional request checks (If-Match) and
+	// the request body size limit
+	password.SetDefault(password.NewHasher(cfg.Password))
+	etag.SetRequireIfMatch(cfg.RequireIfMatch)
+	validation.SetMaxBodySize(cfg.MaxBodySize)
+
+	// Run a maintenance command (e.g. hash-passwords) instead of the server
+	if handled, err := cli.Run(os.Args[1:], config.DB); handled {
+		if err != nil {
+			log.Fatal("Command failed:", err)
+		}
+		return
+	}
+
+	// Configure access token signing
+	tokens, err := auth.NewTokenManager(cfg.Auth)
+	if err != nil {
+		log.Fatal("Failed to configure authentication:", err)
+	}
+
+	// Fail fast with 503 while the database is failing instead of queueing
+	// for the connection pool, on user and session queries alike
+	dbBreaker := repository.NewDatabaseBreaker(cfg.Breaker)
+	sessions := auth.NewSessionManager(config.DB, tokens, dbBreaker)
+	repo := repository.NewBreakerUserRepository(repository.NewGormUserRepository(config.DB), dbBreaker)
+	users := service.NewUserService(repo, sessions)
+
+	// Permanently delete users that stayed in the trash past the retention
+	stopPurger := trash.StartPurger(config.DB, cfg.Trash)
+
+	// Check the database, migrations and connection pool on readiness probes
+	checker, err := health.NewChecker(config.DB, cfg.Health)
+	if err != nil {
+		log.Fatal("Failed to configure readiness checks:", err)
+	}
+
+	// Collect request and connection pool metrics for GET /metrics
+	appMetrics, err := metrics.New(config.DB)
+	if err != nil {
+		log.Fatal("Failed to configure metrics:", err)
+	}
+
+	// Trace requests and queries (OTEL_TRACES_EXPORTER)
+	stopTracing, err := tracing.Init(cfg.Tracing, "fiber-gorm")
+	if err != nil {
+		log.Fatal("Failed to configure tracing:", err)
 	}
-	log.Println("Database synchronized")
 
 	// Create Fiber app
+	// Unknown routes, unsupported methods and panics are answered with problems
 	app := fiber.New(fiber.Config{
-		AppName: "Fiber + GORM CRUD API",
+		AppName:      "Fiber + GORM CRUD API",
+		ErrorHandler: middleware.ErrorHandler,
+		BodyLimit:    int(validation.MaxBodySize()),
+
+		DisableStartupMessage: true,
 	})
 
 	// Middleware
-	app.Use(logger.New())
+	app.Use(middleware.RequestID())
+	app.Use(middleware.Metrics(appMetrics))
+	app.Use(middleware.Tracing())
+	app.Use(middleware.AccessLog())
 	app.Use(recover.New())
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
+		AllowOrigins:  "*",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, If-Match, If-None-Match",
+		AllowMethods:  "GET, POST, PUT, PATCH, DELETE, OPTIONS",
+		ExposeHeaders: "ETag, Link, Accept-Patch, X-Request-ID",
 	}))
 
 	// Health check endpoint
@@ -54,19 +144,31 @@ func main() {
 		})
 	})
 
-	// Setup user routes
-	routes.SetupUserRoutes(app)
+	// Setup liveness and readiness probes
+	routes.SetupHealthRoutes(app, checker)
+
+	// Expose Prometheus metrics
+	routes.SetupMetricsRoutes(app, appMetrics)
 
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3001"
+	// Setup auth, user and role routes
+	routes.SetupAuthRoutes(app, users)
+	routes.SetupUserRoutes(app, users, tokens)
+	routes.SetupRoleRoutes(app, users, tokens)
+
+	// Serve the OpenAPI document of the routes above and the Swagger UI
+	if err := routes.SetupDocsRoutes(app); err != nil {
+		log.Fatal("Failed to build the OpenAPI document:", err)
 	}
 
-	// Start server
-	addr := fmt.Sprintf("0.0.0.0:%s", port)
-	log.Printf("Server is running on port %s", port)
-	if err := app.Listen(addr); err != nil {
+	// Start server. On SIGINT/SIGTERM it drains in-flight requests, then the
+	// purger stops and the connection pool closes.
+	addr := fmt.Sprintf("0.0.0.0:%d", cfg.Port)
+	log.Printf("Server is running on port %d", cfg.Port)
+	server := lifecycle.Server{
+		Serve:    func() error { return app.Listen(addr) },
+		Shutdown: app.ShutdownWithContext,
+	}
+	if err := lifecycle.Run(cfg.Shutdown, server, stopPurger, config.CloseDatabase, stopTracing); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"common/logging"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AccessLog logs every request with its status and latency. It runs after
+// RequestID, so the line carries the request ID.
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			writeError(c, err)
+		}
+		// The line is written before the handler returns, so the strings need no copy
+		logging.Access(c.UserContext(), logging.Request{
+			Method:    c.Method(),
+			Path:      c.Path(),
+			Route:     routeTemplate(c),
+			Status:    c.Response().StatusCode(),
+			Bytes:     len(c.Response().Body()),
+			Duration:  time.Since(start),
+			RemoteIP:  c.IP(),
+			UserAgent: c.Get(fiber.HeaderUserAgent),
+		})
+		return nil
+	}
+}
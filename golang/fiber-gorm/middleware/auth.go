@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"common/auth"
+	"common/problem"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Authenticate validates the bearer access token and stores the
+// authenticated principal in the user context
+func Authenticate(tokens *auth.TokenManager) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, ok := auth.BearerToken(c.Get(fiber.HeaderAuthorization))
+		if !ok {
+			return unauthorized(c, problem.CodeUnauthorized, "Missing bearer token")
+		}
+
+		principal, err := tokens.Parse(token)
+		if err != nil {
+			return unauthorized(c, problem.CodeInvalidToken, err.Error())
+		}
+
+		c.SetUserContext(auth.WithPrincipal(c.UserContext(), principal))
+		return c.Next()
+	}
+}
+
+func unauthorized(c *fiber.Ctx, code, message string) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api"`)
+	return SendProblem(c, problem.New(fiber.StatusUnauthorized, code, message))
+}
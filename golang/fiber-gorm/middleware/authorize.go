@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"common/auth"
+	"common/problem"
+	"common/rbac"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Authorize checks the authenticated principal against the shared access
+// policy. The :id route parameter, when present, is the user the request
+// targets. Must be used after Authenticate.
+func Authorize(perm rbac.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, _ := auth.PrincipalFrom(c.UserContext())
+
+		if !rbac.Can(principal, perm, rbac.OwnerID(c.Params("id"))) {
+			return SendProblem(c, problem.New(fiber.StatusForbidden, problem.CodeForbidden, "You do not have permission to perform this action"))
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"common/metrics"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics records the count and latency of every request by route template
+// and status code
+func Metrics(m *metrics.Metrics) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		done := m.Begin()
+		if err := c.Next(); err != nil {
+			writeError(c, err)
+		}
+		// Method values are only valid until the handler returns
+		done(strings.Clone(c.Method()), routeTemplate(c), c.Response().StatusCode())
+		return nil
+	}
+}
+
+// writeError writes the response of an error returned down the chain now,
+// rather than after the middleware returns, so its status can be recorded
+func writeError(c *fiber.Ctx, err error) {
+	if err := c.App().ErrorHandler(c, err); err != nil {
+		c.Status(fiber.StatusInternalServerError)
+	}
+}
+
+// routeTemplate returns the template of the route the request matched, or
+// "" if it matched none. Then the last route run is middleware such as
+// CORS, whose path is only a prefix of the request path.
+func routeTemplate(c *fiber.Ctx) string {
+	template := c.Route().Path
+	if !matches(template, c.Path()) {
+		return ""
+	}
+	return template
+}
+
+// matches reports whether a route template such as /api/users/:id matches
+// a request path
+func matches(template, path string) bool {
+	want := strings.FieldsFunc(template, func(r rune) bool { return r == '/' })
+	got := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	if len(want) != len(got) {
+		return false
+	}
+	for i, segment := range want {
+		if !strings.HasPrefix(segment, ":") && !strings.EqualFold(segment, got[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"common/problem"
+	"common/requestid"
+	"common/service"
+	"common/validation"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SendProblem responds with an RFC 7807 problem
+func SendProblem(c *fiber.Ctx, p *problem.Problem) error {
+	id := requestid.From(c.UserContext())
+	if id == "" {
+		// Requests fasthttp rejects, e.g. for an oversized body, never reach
+		// the RequestID middleware
+		id = requestid.FromHeader(strings.Clone(c.Get(requestid.Header)))
+		c.Set(requestid.Header, id)
+	}
+	p.ForRequest(c.Method(), c.Path(), id)
+	if p.RetryAfter > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(p.RetryAfter))
+	}
+	return c.Status(p.Status).JSON(p, problem.MediaType)
+}
+
+// ErrorHandler is the error handler of the app. It answers the errors fiber
+// produces on its own, such as unknown routes, unsupported methods and
+// recovered panics, with problems.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	p := problem.Internal(err)
+	var fe *fiber.Error
+	switch {
+	case errors.As(err, &fe) && fe.Code == fiber.StatusRequestEntityTooLarge:
+		p = service.Problem(validation.ErrBodyTooLarge)
+	case errors.As(err, &fe) && fe.Code < fiber.StatusInternalServerError:
+		p = problem.FromStatus(fe.Code)
+	}
+	return SendProblem(c, p)
+}
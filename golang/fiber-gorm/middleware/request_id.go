@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"common/requestid"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestID assigns every request an ID, the client's X-Request-ID when it
+// sent a usable one, stores it in the user context and echoes it in the
+// response
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Header values are only valid until the handler returns
+		id := requestid.FromHeader(strings.Clone(c.Get(requestid.Header)))
+		c.Set(requestid.Header, id)
+		c.SetUserContext(requestid.WithContext(c.UserContext(), id))
+		return c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"common/tracing"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Tracing runs every request in a server span that continues the trace of
+// its traceparent header. Handlers reach the span through the user context,
+// so the queries they run become its children.
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Request values are only valid until the handler returns, and the
+		// span outlives it
+		method, path := strings.Clone(c.Method()), strings.Clone(c.Path())
+		ctx, span := tracing.StartServer(c.UserContext(), headerCarrier{c}, method, path)
+		c.SetUserContext(ctx)
+		if err := c.Next(); err != nil {
+			writeError(c, err)
+		}
+		tracing.EndServer(span, method, routeTemplate(c), c.Response().StatusCode())
+		return nil
+	}
+}
+
+// headerCarrier reads the trace context from the request headers
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return strings.Clone(h.c.Get(key))
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.c.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
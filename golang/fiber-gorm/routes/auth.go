@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"common/dto"
+	"common/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupAuthRoutes sets up authentication routes
+func SetupAuthRoutes(app *fiber.App, users *service.UserService) {
+	// LOGIN - 액세스/리프레시 토큰 발급
+	app.Post("/api/auth/login", login(users))
+
+	// REFRESH - 리프레시 토큰 교체 및 액세스 토큰 재발급
+	app.Post("/api/auth/refresh", refresh(users))
+
+	// LOGOUT - 리프레시 토큰 폐기
+	app.Post("/api/auth/logout", logout(users))
+}
+
+// login authenticates a user by email or username and starts a new session
+func login(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req dto.LoginRequest
+
+		if err := bindJSON(c, &req); err != nil {
+			return sendError(c, err)
+		}
+
+		pair, user, err := users.Login(c.UserContext(), req)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    dto.NewTokenResponse(pair, user),
+		})
+	}
+}
+
+// refresh rotates a refresh token and issues a new token pair
+func refresh(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req dto.RefreshRequest
+
+		if err := bindJSON(c, &req); err != nil {
+			return sendError(c, err)
+		}
+
+		pair, user, err := users.Refresh(c.UserContext(), req)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    dto.NewTokenResponse(pair, user),
+		})
+	}
+}
+
+// logout revokes the session the refresh token belongs to
+func logout(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req dto.RefreshRequest
+
+		if err := bindJSON(c, &req); err != nil {
+			return sendError(c, err)
+		}
+
+		if err := users.Logout(c.UserContext(), req); err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"message": "Logged out successfully",
+		})
+	}
+}
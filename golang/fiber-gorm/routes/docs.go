@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"common/openapi"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// SetupDocsRoutes serves the OpenAPI document of every route registered so
+// far and the Swagger UI. Call it after all other routes are set up.
+func SetupDocsRoutes(app *fiber.App) error {
+	var document []byte
+
+	// DOCS - OpenAPI 문서
+	app.Get("/openapi.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(document)
+	})
+
+	// DOCS - Swagger UI 페이지 및 정적 파일
+	docs := adaptor.HTTPHandler(openapi.DocsHandler())
+	app.Get("/docs", docs)
+	app.Get("/docs/:file", func(c *fiber.Ctx) error {
+		// The adaptor drops the request ID, so unknown files get their
+		// problem from the error handler instead of DocsHandler
+		if !openapi.DocsAsset(c.Params("file")) {
+			return fiber.ErrNotFound
+		}
+		return docs(c)
+	})
+
+	// Middleware registered with Use is left out
+	registered := app.GetRoutes(true)
+	routes := make([]openapi.Route, 0, len(registered))
+	for _, route := range registered {
+		routes = append(routes, openapi.Route{Method: route.Method, Path: route.Path})
+	}
+
+	var err error
+	document, err = openapi.Build(routes)
+	return err
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"common/health"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupHealthRoutes sets up the liveness and readiness probes
+func SetupHealthRoutes(app *fiber.App, checker *health.Checker) {
+	// HEALTH - 프로세스 생존 확인 (liveness)
+	app.Get("/healthz", func(c *fiber.Ctx) error {
+		return c.JSON(checker.Live())
+	})
+
+	// HEALTH - 트래픽 수신 가능 여부 확인 (readiness: DB, 마이그레이션, 연결 풀, 종료 중 여부)
+	app.Get("/readyz", func(c *fiber.Ctx) error {
+		report := checker.Ready(c.UserContext())
+		return c.Status(readyStatus(report)).JSON(report)
+	})
+}
+
+// readyStatus is 200 for a ready instance and 503 otherwise
+func readyStatus(report health.Report) int {
+	if report.OK() {
+		return fiber.StatusOK
+	}
+	return fiber.StatusServiceUnavailable
+}
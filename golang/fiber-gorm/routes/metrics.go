@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"common/metrics"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// SetupMetricsRoutes serves the Prometheus metrics
+func SetupMetricsRoutes(app *fiber.App, m *metrics.Metrics) {
+	// METRICS - Prometheus 수집 엔드포인트
+	app.Get("/metrics", adaptor.HTTPHandler(m.Handler()))
+}
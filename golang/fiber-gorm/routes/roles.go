@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"common/auth"
+	"common/dto"
+	"common/rbac"
+	"common/service"
+	"fiber-gorm/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupRoleRoutes sets up role listing and assignment routes
+func SetupRoleRoutes(app *fiber.App, users *service.UserService, tokens *auth.TokenManager) {
+	authenticate := middleware.Authenticate(tokens)
+	authorize := middleware.Authorize
+
+	// READ - 역할 및 권한 목록 조회
+	app.Get("/api/roles", authenticate, authorize(rbac.RolesRead), getRoles)
+
+	// READ - 사용자 역할 조회
+	app.Get("/api/users/:id/roles", authenticate, authorize(rbac.RolesRead), getUserRoles(users))
+
+	// UPDATE - 사용자 역할 지정 (관리자 전용)
+	app.Put("/api/users/:id/roles", authenticate, authorize(rbac.RolesAssign), setUserRoles(users))
+}
+
+// getRoles lists the roles of the access policy with their permissions
+func getRoles(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    dto.NewRoleResponses(),
+	})
+}
+
+// getUserRoles lists the roles held by a user
+func getUserRoles(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := users.Get(c.UserContext(), c.Params("id"))
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    dto.NewUserRolesResponse(user),
+		})
+	}
+}
+
+// setUserRoles replaces the roles held by a user
+func setUserRoles(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req dto.UserRolesRequest
+		if err := bindJSON(c, &req); err != nil {
+			return sendError(c, err)
+		}
+
+		user, err := users.SetRoles(c.UserContext(), c.Params("id"), req)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    dto.NewUserRolesResponse(user),
+		})
+	}
+}
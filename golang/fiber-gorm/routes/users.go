@@ -1,170 +1,219 @@
 package routes
 
 import (
-	"fiber-gorm/config"
-	"fiber-gorm/models"
-	"strconv"
+	"common/auth"
+	"common/dto"
+	"common/etag"
+	"common/models"
+	"common/patch"
+	"common/query"
+	"common/rbac"
+	"common/repository"
+	"common/service"
+	"common/validation"
+	"errors"
+	"fiber-gorm/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // SetupUserRoutes sets up all user-related routes
-func SetupUserRoutes(app *fiber.App) {
-	// CREATE - 사용자 생성
-	app.Post("/api/users", createUser)
+func SetupUserRoutes(app *fiber.App, users *service.UserService, tokens *auth.TokenManager) {
+	authenticate := middleware.Authenticate(tokens)
+	authorize := middleware.Authorize
+
+	// CREATE - 사용자 생성 (회원가입, 인증 불필요)
+	app.Post("/api/users", createUser(users))
 
 	// READ - 모든 사용자 조회
-	app.Get("/api/users", getUsers)
+	app.Get("/api/users", authenticate, authorize(rbac.UsersList), getUsers(users))
+
+	// READ - 삭제된 사용자(휴지통) 조회 (관리자 전용)
+	app.Get("/api/users/trash", authenticate, authorize(rbac.UsersTrash), getDeletedUsers(users))
+
+	// UPDATE - 삭제된 사용자 복구 (관리자 전용)
+	app.Post("/api/users/:id/restore", authenticate, authorize(rbac.UsersTrash), restoreUser(users))
 
 	// READ - 특정 사용자 조회
-	app.Get("/api/users/:id", getUser)
+	app.Get("/api/users/:id", authenticate, authorize(rbac.UsersRead), getUser(users))
+
+	// UPDATE - 사용자 수정 (전체 교체)
+	app.Put("/api/users/:id", authenticate, authorize(rbac.UsersUpdate), updateUser(users))
 
-	// UPDATE - 사용자 수정
-	app.Put("/api/users/:id", updateUser)
+	// UPDATE - 사용자 부분 수정 (JSON Merge Patch / JSON Patch)
+	app.Patch("/api/users/:id", authenticate, authorize(rbac.UsersUpdate), patchUser(users))
 
 	// DELETE - 사용자 삭제
-	app.Delete("/api/users/:id", deleteUser)
-}
+	app.Delete("/api/users/:id", authenticate, authorize(rbac.UsersDelete), deleteUser(users))
 
-// createUser creates a new user
-func createUser(c *fiber.Ctx) error {
-	var user models.User
+	// DELETE - 사용자의 모든 세션(리프레시 토큰) 폐기 (관리자 전용)
+	app.Delete("/api/users/:id/sessions", authenticate, authorize(rbac.SessionsRevoke), revokeSessions(users))
+}
 
-	if err := c.BodyParser(&user); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   err.Error(),
-		})
-	}
+// sendError responds with the problem a service error maps to
+func sendError(c *fiber.Ctx, err error) error {
+	return middleware.SendProblem(c, service.Problem(err))
+}
 
-	if err := config.DB.Create(&user).Error; err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   err.Error(),
-		})
-	}
+// bindJSON strictly decodes the request body into v. The app rejects bodies
+// over the size limit before a handler runs.
+func bindJSON(c *fiber.Ctx, v interface{}) error {
+	return validation.Decode(c.Body(), v)
+}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+// sendUser responds with a user and its ETag
+func sendUser(c *fiber.Ctx, status int, user *models.User) error {
+	c.Set("ETag", etag.For(user.ID, user.Version))
+	return c.Status(status).JSON(fiber.Map{
 		"success": true,
-		"data":    user,
+		"data":    dto.NewUserResponse(user),
 	})
 }
 
-// getUsers retrieves all users
-func getUsers(c *fiber.Ctx) error {
-	var users []models.User
+// createUser creates a new user
+func createUser(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req dto.CreateUserRequest
 
-	if err := config.DB.Order("id ASC").Find(&users).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   err.Error(),
-		})
+		if err := bindJSON(c, &req); err != nil {
+			return sendError(c, err)
+		}
+
+		user, err := users.Create(c.UserContext(), req)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return sendUser(c, fiber.StatusCreated, user)
 	}
+}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"data":    users,
-	})
+// getUsers retrieves all users
+func getUsers(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return listUsers(c, users, repository.Active, query.Users)
+	}
 }
 
-// getUser retrieves a specific user by ID
-func getUser(c *fiber.Ctx) error {
-	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid user ID",
-		})
+// getDeletedUsers lists soft-deleted users, most recently deleted first
+func getDeletedUsers(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return listUsers(c, users, repository.Trashed, query.DeletedUsers)
 	}
+}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"error":   "User not found",
-		})
+// listUsers responds with one page of the users of a scope
+func listUsers(c *fiber.Ctx, users *service.UserService, scope repository.Scope, spec *query.Spec) error {
+	page, err := users.List(c.UserContext(), scope, spec, c.Path(), string(c.Request().URI().QueryString()))
+	if err != nil {
+		return sendError(c, err)
 	}
 
+	c.Set("Link", page.Link)
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    user,
+		"data":    dto.NewUserResponses(page.Users),
+		"meta":    page.Meta,
 	})
 }
 
-// updateUser updates an existing user
-func updateUser(c *fiber.Ctx) error {
-	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid user ID",
-		})
+// getUser retrieves a specific user by ID
+func getUser(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := users.Get(c.UserContext(), c.Params("id"))
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		tag := etag.For(user.ID, user.Version)
+		if etag.NotModified(c.Get("If-None-Match"), tag) {
+			c.Set("ETag", tag)
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+
+		return sendUser(c, fiber.StatusOK, user)
 	}
+}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"error":   "User not found",
-		})
-	}
+// updateUser replaces a user's email, username and password
+func updateUser(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var updateData dto.UpdateUserRequest
+		if err := bindJSON(c, &updateData); err != nil {
+			return sendError(c, err)
+		}
 
-	var updateData models.User
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   err.Error(),
-		})
-	}
+		user, err := users.Replace(c.UserContext(), c.Params("id"), c.Get("If-Match"), updateData)
+		if err != nil {
+			return sendError(c, err)
+		}
 
-	// Update user fields
-	if err := config.DB.Model(&user).Updates(map[string]interface{}{
-		"email":    updateData.Email,
-		"username": updateData.Username,
-		"password": updateData.Password,
-	}).Error; err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   err.Error(),
-		})
+		return sendUser(c, fiber.StatusOK, user)
 	}
+}
 
-	// Fetch updated user
-	config.DB.First(&user, id)
-
-	return c.JSON(fiber.Map{
-		"success": true,
-		"data":    user,
-	})
+// patchUser updates only the fields supplied by a merge patch or JSON patch
+func patchUser(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := users.Patch(c.UserContext(), c.Params("id"), c.Get("If-Match"), c.Get(fiber.HeaderContentType), c.Body())
+		if err != nil {
+			if errors.Is(err, patch.ErrUnsupportedMediaType) {
+				c.Set("Accept-Patch", patch.AcceptPatch)
+			}
+			return sendError(c, err)
+		}
+
+		return sendUser(c, fiber.StatusOK, user)
+	}
 }
 
-// deleteUser deletes a user
-func deleteUser(c *fiber.Ctx) error {
-	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid user ID",
+// deleteUser moves a user to the trash, or removes it permanently with ?hard=true
+func deleteUser(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		hard, err := service.ParseHard(c.Query("hard"))
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := users.Delete(c.UserContext(), c.Params("id"), c.Get("If-Match"), hard); err != nil {
+			return sendError(c, err)
+		}
+
+		message := "User deleted successfully"
+		if hard {
+			message = "User permanently deleted"
+		}
+		return c.JSON(fiber.Map{
+			"success": true,
+			"message": message,
 		})
 	}
+}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"error":   "User not found",
-		})
+// restoreUser moves a soft-deleted user out of the trash
+func restoreUser(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := users.Restore(c.UserContext(), c.Params("id"))
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return sendUser(c, fiber.StatusOK, user)
 	}
+}
 
-	if err := config.DB.Delete(&user).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   err.Error(),
+// revokeSessions revokes every refresh token of a user (admin only)
+func revokeSessions(users *service.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		revoked, err := users.RevokeSessions(c.UserContext(), c.Params("id"))
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"message": "Sessions revoked successfully",
+			"data":    fiber.Map{"revoked": revoked},
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "User deleted successfully",
-	})
 }
@@ -1,48 +1,142 @@
 package main
 
 import (
+	"common/auth"
+	"common/cli"
+	"common/etag"
+	"common/health"
+	"common/lifecycle"
+	"common/logging"
+	"common/metrics"
+	"common/migrations"
+	"common/password"
+	"common/rbac"
+	"common/repository"
+	"common/service"
+	"common/settings"
+	"common/tracing"
+	"common/trash"
+	"common/validation"
 	"fmt"
 	"gin-gorm/config"
-	"gin-gorm/models"
+	"gin-gorm/middleware"
 	"gin-gorm/routes"
 	"log"
+	"net/http"
 	"os"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found, using system environment variables")
+	// Load the configuration: defaults, config file, .env and environment
+	cfg, err := settings.Load()
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+
+	// Write JSON logs through slog
+	logging.Init(cfg.Logging)
+
+	// Print the configuration (config print) instead of the server
+	if handled, err := cli.RunConfig(os.Args[1:], cfg); handled {
+		if err != nil {
+			log.Fatal("Command failed:", err)
+		}
+		return
 	}
 
 	// Initialize database connection
-	if err := config.InitDatabase(); err != nil {
+	if err := config.InitDatabase(cfg.Database); err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
-	// Auto migrate the schema
-	if err := config.DB.AutoMigrate(&models.User{}); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// Apply schema migrations, unless the command manages them itself
+	if !cli.ManagesSchema(os.Args[1:]) {
+		if err := migrations.ApplyOnStart(config.DB, cfg.MigrateOnStart); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		log.Println("Database synchronized")
+
+		// Make sure the roles of the access policy exist
+		if err := rbac.SeedRoles(config.DB); err != nil {
+			log.Fatal("Failed to seed roles:", err)
+		}
+	}
+
+	// Configure password hashing, conditional request checks (If-Match) and
+	// the request body size limit
+	password.SetDefault(password.NewHasher(cfg.Password))
+	etag.SetRequireIfMatch(cfg.RequireIfMatch)
+	validation.SetMaxBodySize(cfg.MaxBodySize)
+
+	// Run a maintenance command (e.g. hash-passwords) instead of the server
+	if handled, err := cli.Run(os.Args[1:], config.DB); handled {
+		if err != nil {
+			log.Fatal("Command failed:", err)
+		}
+		return
+	}
+
+	// Configure access token signing
+	tokens, err := auth.NewTokenManager(cfg.Auth)
+	if err != nil {
+		log.Fatal("Failed to configure authentication:", err)
+	}
+
+	// Fail fast with 503 while the database is failing instead of queueing
+	// for the connection pool, on user and session queries alike
+	dbBreaker := repository.NewDatabaseBreaker(cfg.Breaker)
+	sessions := auth.NewSessionManager(config.DB, tokens, dbBreaker)
+	repo := repository.NewBreakerUserRepository(repository.NewGormUserRepository(config.DB), dbBreaker)
+	users := service.NewUserService(repo, sessions)
+
+	// Permanently delete users that stayed in the trash past the retention
+	stopPurger := trash.StartPurger(config.DB, cfg.Trash)
+
+	// Check the database, migrations and connection pool on readiness probes
+	checker, err := health.NewChecker(config.DB, cfg.Health)
+	if err != nil {
+		log.Fatal("Failed to configure readiness checks:", err)
+	}
+
+	// Collect request and connection pool metrics for GET /metrics
+	appMetrics, err := metrics.New(config.DB)
+	if err != nil {
+		log.Fatal("Failed to configure metrics:", err)
+	}
+
+	// Trace requests and queries (OTEL_TRACES_EXPORTER)
+	stopTracing, err := tracing.Init(cfg.Tracing, "gin-gorm")
+	if err != nil {
+		log.Fatal("Failed to configure tracing:", err)
 	}
-	log.Println("Database synchronized")
 
 	// Set Gin mode
 	if os.Getenv("GIN_MODE") == "" {
 		gin.SetMode(gin.DebugMode)
 	}
 
-	// Create Gin router
-	router := gin.Default()
+	// Create Gin router. Panics, unknown routes and unsupported methods are
+	// answered with problems like every other error. Metrics, tracing and the
+	// access log come first so they see the status of recovered panics.
+	router := gin.New()
+	router.Use(middleware.Metrics(appMetrics), middleware.Tracing())
+
+	// Assign every request an ID (X-Request-ID) and log it
+	router.Use(middleware.RequestID(), middleware.AccessLog())
+	router.Use(gin.CustomRecovery(middleware.Recover))
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(middleware.NotFound)
+	router.NoMethod(middleware.MethodNotAllowed)
 
 	// Setup CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, If-Match, If-None-Match")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag, Link, Accept-Patch, X-Request-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -60,19 +154,29 @@ func main() {
 		})
 	})
 
-	// Setup user routes
-	routes.SetupUserRoutes(router)
+	// Setup liveness and readiness probes
+	routes.SetupHealthRoutes(router, checker)
+
+	// Expose Prometheus metrics
+	routes.SetupMetricsRoutes(router, appMetrics)
+
+	// Setup auth, user and role routes
+	routes.SetupAuthRoutes(router, users)
+	routes.SetupUserRoutes(router, users, tokens)
+	routes.SetupRoleRoutes(router, users, tokens)
 
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3001"
+	// Serve the OpenAPI document of the routes above and the Swagger UI
+	if err := routes.SetupDocsRoutes(router); err != nil {
+		log.Fatal("Failed to build the OpenAPI document:", err)
 	}
 
-	// Start server
-	addr := fmt.Sprintf("0.0.0.0:%s", port)
-	log.Printf("Server is running on port %s", port)
-	if err := router.Run(addr); err != nil {
+	// Start server. On SIGINT/SIGTERM it drains in-flight requests, then the
+	// purger stops and the connection pool closes.
+	addr := fmt.Sprintf("0.0.0.0:%d", cfg.Port)
+	srv := &http.Server{Addr: addr, Handler: router}
+	log.Printf("Server is running on port %d", cfg.Port)
+	server := lifecycle.Server{Serve: srv.ListenAndServe, Shutdown: srv.Shutdown}
+	if err := lifecycle.Run(cfg.Shutdown, server, stopPurger, config.CloseDatabase, stopTracing); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"common/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog logs every request with its status and latency. It runs after
+// RequestID, so the line carries the request ID.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		logging.Access(c.Request.Context(), logging.Request{
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Route:     c.FullPath(),
+			Status:    c.Writer.Status(),
+			Bytes:     c.Writer.Size(),
+			Duration:  time.Since(start),
+			RemoteIP:  c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+	}
+}
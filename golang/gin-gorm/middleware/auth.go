@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"common/auth"
+	"common/problem"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate validates the bearer access token and stores the
+// authenticated principal in the request context
+func Authenticate(tokens *auth.TokenManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := auth.BearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			unauthorized(c, problem.CodeUnauthorized, "Missing bearer token")
+			return
+		}
+
+		principal, err := tokens.Parse(token)
+		if err != nil {
+			unauthorized(c, problem.CodeInvalidToken, err.Error())
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+func unauthorized(c *gin.Context, code, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	SendProblem(c, problem.New(http.StatusUnauthorized, code, message))
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"common/auth"
+	"common/problem"
+	"common/rbac"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize checks the authenticated principal against the shared access
+// policy. The :id route parameter, when present, is the user the request
+// targets. Must be used after Authenticate.
+func Authorize(perm rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := auth.PrincipalFrom(c.Request.Context())
+
+		if !rbac.Can(principal, perm, rbac.OwnerID(c.Param("id"))) {
+			SendProblem(c, problem.New(http.StatusForbidden, problem.CodeForbidden, "You do not have permission to perform this action"))
+			return
+		}
+
+		c.Next()
+	}
+}
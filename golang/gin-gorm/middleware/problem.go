@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"common/problem"
+	"common/requestid"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SendProblem responds with an RFC 7807 problem and stops the handler chain
+func SendProblem(c *gin.Context, p *problem.Problem) {
+	p.ForRequest(c.Request.Method, c.Request.URL.Path, requestid.From(c.Request.Context()))
+	c.Header("Content-Type", problem.MediaType)
+	if p.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(p.RetryAfter))
+	}
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// NotFound answers requests that match no route
+func NotFound(c *gin.Context) {
+	SendProblem(c, problem.FromStatus(http.StatusNotFound))
+}
+
+// MethodNotAllowed answers requests whose path is routed for other methods only
+func MethodNotAllowed(c *gin.Context) {
+	SendProblem(c, problem.FromStatus(http.StatusMethodNotAllowed))
+}
+
+// Recover answers a request whose handler panicked with an internal error
+func Recover(c *gin.Context, recovered interface{}) {
+	SendProblem(c, problem.Internal(fmt.Errorf("panic: %v", recovered)))
+}
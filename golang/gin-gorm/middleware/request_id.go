@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"common/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID assigns every request an ID, the client's X-Request-ID when it
+// sent a usable one, stores it in the request context and echoes it in the
+// response
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := requestid.FromHeader(c.GetHeader(requestid.Header))
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.WithContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
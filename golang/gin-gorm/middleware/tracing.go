@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"common/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Tracing runs every request in a server span that continues the trace of
+// its traceparent header. Handlers reach the span through the request
+// context, so the queries they run become its children.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, span := tracing.StartServer(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header), c.Request.Method, c.Request.URL.Path)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		tracing.EndServer(span, c.Request.Method, c.FullPath(), c.Writer.Status())
+	}
+}
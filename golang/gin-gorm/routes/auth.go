@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"common/dto"
+	"common/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAuthRoutes sets up authentication routes
+func SetupAuthRoutes(router *gin.Engine, users *service.UserService) {
+	// LOGIN - 액세스/리프레시 토큰 발급
+	router.POST("/api/auth/login", login(users))
+
+	// REFRESH - 리프레시 토큰 교체 및 액세스 토큰 재발급
+	router.POST("/api/auth/refresh", refresh(users))
+
+	// LOGOUT - 리프레시 토큰 폐기
+	router.POST("/api/auth/logout", logout(users))
+}
+
+// login authenticates a user by email or username and starts a new session
+func login(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.LoginRequest
+
+		if err := bindJSON(c, &req); err != nil {
+			sendError(c, err)
+			return
+		}
+
+		pair, user, err := users.Login(c.Request.Context(), req)
+		if err != nil {
+			sendError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    dto.NewTokenResponse(pair, user),
+		})
+	}
+}
+
+// refresh rotates a refresh token and issues a new token pair
+func refresh(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.RefreshRequest
+
+		if err := bindJSON(c, &req); err != nil {
+			sendError(c, err)
+			return
+		}
+
+		pair, user, err := users.Refresh(c.Request.Context(), req)
+		if err != nil {
+			sendError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    dto.NewTokenResponse(pair, user),
+		})
+	}
+}
+
+// logout revokes the session the refresh token belongs to
+func logout(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.RefreshRequest
+
+		if err := bindJSON(c, &req); err != nil {
+			sendError(c, err)
+			return
+		}
+
+		if err := users.Logout(c.Request.Context(), req); err != nil {
+			sendError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Logged out successfully",
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"common/openapi"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupDocsRoutes serves the OpenAPI document of every route registered so
+// far and the Swagger UI. Call it after all other routes are set up.
+func SetupDocsRoutes(router *gin.Engine) error {
+	var document []byte
+
+	// DOCS - OpenAPI 문서
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", document)
+	})
+
+	// DOCS - Swagger UI 페이지 및 정적 파일
+	docs := gin.WrapH(openapi.DocsHandler())
+	router.GET("/docs", docs)
+	router.GET("/docs/:file", docs)
+
+	registered := router.Routes()
+	routes := make([]openapi.Route, 0, len(registered))
+	for _, route := range registered {
+		routes = append(routes, openapi.Route{Method: route.Method, Path: route.Path})
+	}
+
+	var err error
+	document, err = openapi.Build(routes)
+	return err
+}
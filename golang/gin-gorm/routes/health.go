@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"common/health"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupHealthRoutes sets up the liveness and readiness probes
+func SetupHealthRoutes(router *gin.Engine, checker *health.Checker) {
+	// HEALTH - 프로세스 생존 확인 (liveness)
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, checker.Live())
+	})
+
+	// HEALTH - 트래픽 수신 가능 여부 확인 (readiness: DB, 마이그레이션, 연결 풀, 종료 중 여부)
+	router.GET("/readyz", func(c *gin.Context) {
+		report := checker.Ready(c.Request.Context())
+		c.JSON(readyStatus(report), report)
+	})
+}
+
+// readyStatus is 200 for a ready instance and 503 otherwise
+func readyStatus(report health.Report) int {
+	if report.OK() {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
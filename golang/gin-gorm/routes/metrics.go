@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"common/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupMetricsRoutes serves the Prometheus metrics
+func SetupMetricsRoutes(router *gin.Engine, m *metrics.Metrics) {
+	// METRICS - Prometheus 수집 엔드포인트
+	router.GET("/metrics", gin.WrapH(m.Handler()))
+}
@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"common/auth"
+	"common/dto"
+	"common/rbac"
+	"common/service"
+	"gin-gorm/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoleRoutes sets up role listing and assignment routes
+func SetupRoleRoutes(router *gin.Engine, users *service.UserService, tokens *auth.TokenManager) {
+	authenticate := middleware.Authenticate(tokens)
+	authorize := middleware.Authorize
+
+	// READ - 역할 및 권한 목록 조회
+	router.GET("/api/roles", authenticate, authorize(rbac.RolesRead), getRoles)
+
+	// READ - 사용자 역할 조회
+	router.GET("/api/users/:id/roles", authenticate, authorize(rbac.RolesRead), getUserRoles(users))
+
+	// UPDATE - 사용자 역할 지정 (관리자 전용)
+	router.PUT("/api/users/:id/roles", authenticate, authorize(rbac.RolesAssign), setUserRoles(users))
+}
+
+// getRoles lists the roles of the access policy with their permissions
+func getRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dto.NewRoleResponses(),
+	})
+}
+
+// getUserRoles lists the roles held by a user
+func getUserRoles(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := users.Get(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			sendError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    dto.NewUserRolesResponse(user),
+		})
+	}
+}
+
+// setUserRoles replaces the roles held by a user
+func setUserRoles(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.UserRolesRequest
+		if err := bindJSON(c, &req); err != nil {
+			sendError(c, err)
+			return
+		}
+
+		user, err := users.SetRoles(c.Request.Context(), c.Param("id"), req)
+		if err != nil {
+			sendError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    dto.NewUserRolesResponse(user),
+		})
+	}
+}
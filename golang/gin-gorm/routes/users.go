@@ -1,183 +1,241 @@
 package routes
 
 import (
-	"gin-gorm/config"
-	"gin-gorm/models"
+	"common/auth"
+	"common/dto"
+	"common/etag"
+	"common/models"
+	"common/patch"
+	"common/query"
+	"common/rbac"
+	"common/repository"
+	"common/service"
+	"common/validation"
+	"errors"
+	"gin-gorm/middleware"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupUserRoutes sets up all user-related routes
-func SetupUserRoutes(router *gin.Engine) {
-	// CREATE - 사용자 생성
-	router.POST("/api/users", createUser)
+func SetupUserRoutes(router *gin.Engine, users *service.UserService, tokens *auth.TokenManager) {
+	authenticate := middleware.Authenticate(tokens)
+	authorize := middleware.Authorize
+
+	// CREATE - 사용자 생성 (회원가입, 인증 불필요)
+	router.POST("/api/users", createUser(users))
 
 	// READ - 모든 사용자 조회
-	router.GET("/api/users", getUsers)
+	router.GET("/api/users", authenticate, authorize(rbac.UsersList), getUsers(users))
+
+	// READ - 삭제된 사용자(휴지통) 조회 (관리자 전용)
+	router.GET("/api/users/trash", authenticate, authorize(rbac.UsersTrash), getDeletedUsers(users))
+
+	// UPDATE - 삭제된 사용자 복구 (관리자 전용)
+	router.POST("/api/users/:id/restore", authenticate, authorize(rbac.UsersTrash), restoreUser(users))
 
 	// READ - 특정 사용자 조회
-	router.GET("/api/users/:id", getUser)
+	router.GET("/api/users/:id", authenticate, authorize(rbac.UsersRead), getUser(users))
+
+	// UPDATE - 사용자 수정 (전체 교체)
+	router.PUT("/api/users/:id", authenticate, authorize(rbac.UsersUpdate), updateUser(users))
 
-	// UPDATE - 사용자 수정
-	router.PUT("/api/users/:id", updateUser)
+	// UPDATE - 사용자 부분 수정 (JSON Merge Patch / JSON Patch)
+	router.PATCH("/api/users/:id", authenticate, authorize(rbac.UsersUpdate), patchUser(users))
 
 	// DELETE - 사용자 삭제
-	router.DELETE("/api/users/:id", deleteUser)
-}
+	router.DELETE("/api/users/:id", authenticate, authorize(rbac.UsersDelete), deleteUser(users))
 
-// createUser creates a new user
-func createUser(c *gin.Context) {
-	var user models.User
+	// DELETE - 사용자의 모든 세션(리프레시 토큰) 폐기 (관리자 전용)
+	router.DELETE("/api/users/:id/sessions", authenticate, authorize(rbac.SessionsRevoke), revokeSessions(users))
+}
 
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   err.Error(),
-		})
-		return
-	}
+// sendError responds with the problem a service error maps to
+func sendError(c *gin.Context, err error) {
+	middleware.SendProblem(c, service.Problem(err))
+}
 
-	if err := config.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   err.Error(),
-		})
-		return
+// bindJSON reads the request body and strictly decodes it into v
+func bindJSON(c *gin.Context, v interface{}) error {
+	body, err := validation.ReadBody(c.Request.Body)
+	if err != nil {
+		return err
 	}
+	return validation.Decode(body, v)
+}
 
-	c.JSON(http.StatusCreated, gin.H{
+// sendUser responds with a user and its ETag
+func sendUser(c *gin.Context, status int, user *models.User) {
+	c.Header("ETag", etag.For(user.ID, user.Version))
+	c.JSON(status, gin.H{
 		"success": true,
-		"data":    user,
+		"data":    dto.NewUserResponse(user),
 	})
 }
 
-// getUsers retrieves all users
-func getUsers(c *gin.Context) {
-	var users []models.User
-
-	if err := config.DB.Order("id ASC").Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   err.Error(),
-		})
-		return
+// createUser creates a new user
+func createUser(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.CreateUserRequest
+
+		if err := bindJSON(c, &req); err != nil {
+			sendError(c, err)
+			return
+		}
+
+		user, err := users.Create(c.Request.Context(), req)
+		if err != nil {
+			sendError(c, err)
+			return
+		}
+
+		sendUser(c, http.StatusCreated, user)
 	}
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    users,
-	})
+// getUsers retrieves all users
+func getUsers(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listUsers(c, users, repository.Active, query.Users)
+	}
 }
 
-// getUser retrieves a specific user by ID
-func getUser(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid user ID",
-		})
-		return
+// getDeletedUsers lists soft-deleted users, most recently deleted first
+func getDeletedUsers(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listUsers(c, users, repository.Trashed, query.DeletedUsers)
 	}
+}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "User not found",
-		})
+// listUsers responds with one page of the users of a scope
+func listUsers(c *gin.Context, users *service.UserService, scope repository.Scope, spec *query.Spec) {
+	page, err := users.List(c.Request.Context(), scope, spec, c.Request.URL.Path, c.Request.URL.RawQuery)
+	if err != nil {
+		sendError(c, err)
 		return
 	}
 
+	c.Header("Link", page.Link)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    user,
+		"data":    dto.NewUserResponses(page.Users),
+		"meta":    page.Meta,
 	})
 }
 
-// updateUser updates an existing user
-func updateUser(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid user ID",
-		})
-		return
+// getUser retrieves a specific user by ID
+func getUser(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := users.Get(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			sendError(c, err)
+			return
+		}
+
+		tag := etag.For(user.ID, user.Version)
+		if etag.NotModified(c.GetHeader("If-None-Match"), tag) {
+			c.Header("ETag", tag)
+			c.Status(http.StatusNotModified)
+			return
+		}
+
+		sendUser(c, http.StatusOK, user)
 	}
+}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "User not found",
-		})
-		return
+// updateUser replaces a user's email, username and password
+func updateUser(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var updateData dto.UpdateUserRequest
+		if err := bindJSON(c, &updateData); err != nil {
+			sendError(c, err)
+			return
+		}
+
+		user, err := users.Replace(c.Request.Context(), c.Param("id"), c.GetHeader("If-Match"), updateData)
+		if err != nil {
+			sendError(c, err)
+			return
+		}
+
+		sendUser(c, http.StatusOK, user)
 	}
+}
 
-	var updateData models.User
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   err.Error(),
-		})
-		return
+// patchUser updates only the fields supplied by a merge patch or JSON patch
+func patchUser(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := validation.ReadBody(c.Request.Body)
+		if err != nil {
+			sendError(c, err)
+			return
+		}
+
+		user, err := users.Patch(c.Request.Context(), c.Param("id"), c.GetHeader("If-Match"), c.GetHeader("Content-Type"), body)
+		if err != nil {
+			if errors.Is(err, patch.ErrUnsupportedMediaType) {
+				c.Header("Accept-Patch", patch.AcceptPatch)
+			}
+			sendError(c, err)
+			return
+		}
+
+		sendUser(c, http.StatusOK, user)
 	}
+}
 
-	// Update user fields
-	if err := config.DB.Model(&user).Updates(map[string]interface{}{
-		"email":    updateData.Email,
-		"username": updateData.Username,
-		"password": updateData.Password,
-	}).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   err.Error(),
+// deleteUser moves a user to the trash, or removes it permanently with ?hard=true
+func deleteUser(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hard, err := service.ParseHard(c.Query("hard"))
+		if err != nil {
+			sendError(c, err)
+			return
+		}
+
+		if err := users.Delete(c.Request.Context(), c.Param("id"), c.GetHeader("If-Match"), hard); err != nil {
+			sendError(c, err)
+			return
+		}
+
+		message := "User deleted successfully"
+		if hard {
+			message = "User permanently deleted"
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": message,
 		})
-		return
 	}
-
-	// Fetch updated user
-	config.DB.First(&user, id)
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    user,
-	})
 }
 
-// deleteUser deletes a user
-func deleteUser(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid user ID",
-		})
-		return
-	}
+// restoreUser moves a soft-deleted user out of the trash
+func restoreUser(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := users.Restore(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			sendError(c, err)
+			return
+		}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "User not found",
-		})
-		return
+		sendUser(c, http.StatusOK, user)
 	}
+}
 
-	if err := config.DB.Delete(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   err.Error(),
+// revokeSessions revokes every refresh token of a user (admin only)
+func revokeSessions(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revoked, err := users.RevokeSessions(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			sendError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Sessions revoked successfully",
+			"data":    gin.H{"revoked": revoked},
 		})
-		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "User deleted successfully",
-	})
 }
@@ -1,45 +1,134 @@
 package main
 
 import (
+	"common/auth"
+	"common/cli"
+	"common/etag"
+	"common/health"
+	"common/lifecycle"
+	"common/logging"
+	"common/metrics"
+	"common/migrations"
+	"common/password"
+	"common/rbac"
+	"common/repository"
+	"common/service"
+	"common/settings"
+	"common/tracing"
+	"common/trash"
+	"common/validation"
 	"fmt"
 	"gorilla-gorm/config"
-	"gorilla-gorm/models"
+	"gorilla-gorm/middleware"
 	"gorilla-gorm/routes"
 	"log"
 	"net/http"
 	"os"
 
 	"github.com/gorilla/mux"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found, using system environment variables")
+	// Load the configuration: defaults, config file, .env and environment
+	cfg, err := settings.Load()
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+
+	// Write JSON logs through slog
+	logging.Init(cfg.Logging)
+
+	// Print the configuration (config print) instead of the server
+	if handled, err := cli.RunConfig(os.Args[1:], cfg); handled {
+		if err != nil {
+			log.Fatal("Command failed:", err)
+		}
+		return
 	}
 
 	// Initialize database connection
-	if err := config.InitDatabase(); err != nil {
+	if err := config.InitDatabase(cfg.Database); err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
-	// Auto migrate the schema
-	if err := config.DB.AutoMigrate(&models.User{}); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// Apply schema migrations, unless the command manages them itself
+	if !cli.ManagesSchema(os.Args[1:]) {
+		if err := migrations.ApplyOnStart(config.DB, cfg.MigrateOnStart); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		log.Println("Database synchronized")
+
+		// Make sure the roles of the access policy exist
+		if err := rbac.SeedRoles(config.DB); err != nil {
+			log.Fatal("Failed to seed roles:", err)
+		}
+	}
+
+	// Configure password hashing, conditional request checks (If-Match) and
+	// the request body size limit
+	password.SetDefault(password.NewHasher(cfg.Password))
+	etag.SetRequireIfMatch(cfg.RequireIfMatch)
+	validation.SetMaxBodySize(cfg.MaxBodySize)
+
+	// Run a maintenance command (e.g. hash-passwords) instead of the server
+	if handled, err := cli.Run(os.Args[1:], config.DB); handled {
+		if err != nil {
+			log.Fatal("Command failed:", err)
+		}
+		return
+	}
+
+	// Configure access token signing
+	tokens, err := auth.NewTokenManager(cfg.Auth)
+	if err != nil {
+		log.Fatal("Failed to configure authentication:", err)
+	}
+
+	// Fail fast with 503 while the database is failing instead of queueing
+	// for the connection pool, on user and session queries alike
+	dbBreaker := repository.NewDatabaseBreaker(cfg.Breaker)
+	sessions := auth.NewSessionManager(config.DB, tokens, dbBreaker)
+	repo := repository.NewBreakerUserRepository(repository.NewGormUserRepository(config.DB), dbBreaker)
+	users := service.NewUserService(repo, sessions)
+
+	// Permanently delete users that stayed in the trash past the retention
+	stopPurger := trash.StartPurger(config.DB, cfg.Trash)
+
+	// Check the database, migrations and connection pool on readiness probes
+	checker, err := health.NewChecker(config.DB, cfg.Health)
+	if err != nil {
+		log.Fatal("Failed to configure readiness checks:", err)
+	}
+
+	// Collect request and connection pool metrics for GET /metrics
+	appMetrics, err := metrics.New(config.DB)
+	if err != nil {
+		log.Fatal("Failed to configure metrics:", err)
+	}
+
+	// Trace requests and queries (OTEL_TRACES_EXPORTER)
+	stopTracing, err := tracing.Init(cfg.Tracing, "gorilla-gorm")
+	if err != nil {
+		log.Fatal("Failed to configure tracing:", err)
 	}
-	log.Println("Database synchronized")
 
 	// Create Gorilla Mux router
 	router := mux.NewRouter()
 
-	// CORS middleware
-	router.Use(func(next http.Handler) http.Handler {
+	// Answer unknown routes and unsupported methods with problems
+	router.NotFoundHandler = http.HandlerFunc(middleware.NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(middleware.MethodNotAllowed)
+
+	// CORS middleware. It wraps the router rather than being registered with
+	// router.Use, because mux only runs middleware for matched routes and
+	// preflight OPTIONS requests match none.
+	cors := func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, If-Match, If-None-Match")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag, Link, Accept-Patch, X-Request-ID")
 
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusNoContent)
@@ -48,15 +137,7 @@ func main() {
 
 			next.ServeHTTP(w, r)
 		})
-	})
-
-	// Logging middleware
-	router.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			log.Printf("%s %s", r.Method, r.RequestURI)
-			next.ServeHTTP(w, r)
-		})
-	})
+	}
 
 	// Health check endpoint
 	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -65,19 +146,34 @@ func main() {
 		w.Write([]byte(`{"message":"Gorilla Mux + GORM CRUD API","status":"running"}`))
 	}).Methods("GET")
 
-	// Setup user routes
-	routes.SetupUserRoutes(router)
+	// Setup liveness and readiness probes
+	routes.SetupHealthRoutes(router, checker)
 
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3001"
+	// Expose Prometheus metrics
+	routes.SetupMetricsRoutes(router, appMetrics)
+
+	// Setup auth, user and role routes
+	routes.SetupAuthRoutes(router, users)
+	routes.SetupUserRoutes(router, users, tokens)
+	routes.SetupRoleRoutes(router, users, tokens)
+
+	// Serve the OpenAPI document of the routes above and the Swagger UI
+	if err := routes.SetupDocsRoutes(router); err != nil {
+		log.Fatal("Failed to build the OpenAPI document:", err)
 	}
 
-	// Start server
-	addr := fmt.Sprintf("0.0.0.0:%s", port)
-	log.Printf("Server is running on port %s", port)
-	if err := http.ListenAndServe(addr, router); err != nil {
+	// Every request is measured, traced, gets an ID (X-Request-ID) and is
+	// logged, and panics are answered with problems, including requests no
+	// route matches
+	handler := middleware.Metrics(appMetrics, router)(middleware.Tracing(router)(cors(middleware.RequestID(middleware.AccessLog(router)(middleware.Recover(router))))))
+
+	// Start server. On SIGINT/SIGTERM it drains in-flight requests, then the
+	// purger stops and the connection pool closes.
+	addr := fmt.Sprintf("0.0.0.0:%d", cfg.Port)
+	srv := &http.Server{Addr: addr, Handler: handler}
+	log.Printf("Server is running on port %d", cfg.Port)
+	server := lifecycle.Server{Serve: srv.ListenAndServe, Shutdown: srv.Shutdown}
+	if err := lifecycle.Run(cfg.Shutdown, server, stopPurger, config.CloseDatabase, stopTracing); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
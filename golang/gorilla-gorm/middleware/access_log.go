@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"common/logging"
+	"common/metrics"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// AccessLog logs every request with its status and latency. It wraps the
+// router like Metrics, so requests no route matches are logged too, and
+// runs after RequestID, so the line carries the request ID.
+func AccessLog(router *mux.Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			route := routeTemplate(router, r)
+			if route != "" {
+				// The same route is logged as /api/users/:id by every variant
+				route = metrics.Route(route)
+			}
+			remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				remoteIP = r.RemoteAddr
+			}
+			logging.Access(r.Context(), logging.Request{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Route:     route,
+				Status:    rec.status,
+				Bytes:     rec.bytes,
+				Duration:  time.Since(start),
+				RemoteIP:  remoteIP,
+				UserAgent: r.UserAgent(),
+			})
+		})
+	}
+}
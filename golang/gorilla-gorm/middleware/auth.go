@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"common/auth"
+	"common/problem"
+	"net/http"
+)
+
+// Authenticate validates the bearer access token and stores the
+// authenticated principal in the request context
+func Authenticate(tokens *auth.TokenManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := auth.BearerToken(r.Header.Get("Authorization"))
+			if !ok {
+				unauthorized(w, r, problem.CodeUnauthorized, "Missing bearer token")
+				return
+			}
+
+			principal, err := tokens.Parse(token)
+			if err != nil {
+				unauthorized(w, r, problem.CodeInvalidToken, err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, code, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	SendProblem(w, r, problem.New(http.StatusUnauthorized, code, message))
+}
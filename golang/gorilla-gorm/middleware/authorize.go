@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"common/auth"
+	"common/problem"
+	"common/rbac"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Authorize checks the authenticated principal against the shared access
+// policy. The {id} route variable, when present, is the user the request
+// targets. Must be used after Authenticate.
+func Authorize(perm rbac.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ := auth.PrincipalFrom(r.Context())
+
+			if !rbac.Can(principal, perm, rbac.OwnerID(mux.Vars(r)["id"])) {
+				SendProblem(w, r, problem.New(http.StatusForbidden, problem.CodeForbidden, "You do not have permission to perform this action"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
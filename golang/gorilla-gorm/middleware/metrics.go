@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"common/metrics"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Metrics records the count and latency of every request by route template
+// and status code. It wraps the router rather than being registered with
+// router.Use, so requests no route matches are counted too.
+func Metrics(m *metrics.Metrics, router *mux.Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done := m.Begin()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			done(r.Method, routeTemplate(router, r), rec.status)
+		})
+	}
+}
+
+// routeTemplate returns the template of the route r matches, or "" if it
+// matches none
+func routeTemplate(router *mux.Router, r *http.Request) string {
+	var match mux.RouteMatch
+	if !router.Match(r, &match) || match.Route == nil {
+		return ""
+	}
+	template, _ := match.Route.GetPathTemplate()
+	return template
+}
+
+// statusRecorder remembers the status code and body size a handler wrote
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+	wrote  bool
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wrote {
+		w.status, w.wrote = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wrote = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"common/problem"
+	"common/requestid"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// SendProblem responds with an RFC 7807 problem
+func SendProblem(w http.ResponseWriter, r *http.Request, p *problem.Problem) {
+	p.ForRequest(r.Method, r.URL.Path, requestid.From(r.Context()))
+	w.Header().Set("Content-Type", problem.MediaType)
+	if p.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(p.RetryAfter))
+	}
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// NotFound answers requests that match no route
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	SendProblem(w, r, problem.FromStatus(http.StatusNotFound))
+}
+
+// MethodNotAllowed answers requests whose path is routed for other methods only
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	SendProblem(w, r, problem.FromStatus(http.StatusMethodNotAllowed))
+}
+
+// Recover answers a request whose handler panicked with an internal error
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// net/http uses this panic to abort a response on purpose
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			SendProblem(w, r, problem.Internal(fmt.Errorf("panic: %v", recovered)))
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
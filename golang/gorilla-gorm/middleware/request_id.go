@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"common/requestid"
+	"net/http"
+)
+
+// RequestID assigns every request an ID, the client's X-Request-ID when it
+// sent a usable one, stores it in the request context and echoes it in the
+// response
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestid.FromHeader(r.Header.Get(requestid.Header))
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithContext(r.Context(), id)))
+	})
+}
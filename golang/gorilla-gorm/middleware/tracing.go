@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"common/tracing"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Tracing runs every request in a server span that continues the trace of
+// its traceparent header. Handlers reach the span through the request
+// context, so the queries they run become its children. Like Metrics it
+// wraps the router, so requests no route matches are traced too.
+func Tracing(router *mux.Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := tracing.StartServer(r.Context(), propagation.HeaderCarrier(r.Header), r.Method, r.URL.Path)
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			tracing.EndServer(span, r.Method, routeTemplate(router, r), rec.status)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"common/dto"
+	"common/service"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// SetupAuthRoutes sets up authentication routes
+func SetupAuthRoutes(router *mux.Router, users *service.UserService) {
+	// LOGIN - 액세스/리프레시 토큰 발급
+	router.HandleFunc("/api/auth/login", login(users)).Methods("POST")
+
+	// REFRESH - 리프레시 토큰 교체 및 액세스 토큰 재발급
+	router.HandleFunc("/api/auth/refresh", refresh(users)).Methods("POST")
+
+	// LOGOUT - 리프레시 토큰 폐기
+	router.HandleFunc("/api/auth/logout", logout(users)).Methods("POST")
+}
+
+// login authenticates a user by email or username and starts a new session
+func login(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.LoginRequest
+
+		if err := bindJSON(r, &req); err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		pair, user, err := users.Login(r.Context(), req)
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		sendJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    dto.NewTokenResponse(pair, user),
+		})
+	}
+}
+
+// refresh rotates a refresh token and issues a new token pair
+func refresh(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.RefreshRequest
+
+		if err := bindJSON(r, &req); err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		pair, user, err := users.Refresh(r.Context(), req)
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		sendJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    dto.NewTokenResponse(pair, user),
+		})
+	}
+}
+
+// logout revokes the session the refresh token belongs to
+func logout(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.RefreshRequest
+
+		if err := bindJSON(r, &req); err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		if err := users.Logout(r.Context(), req); err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		sendJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Logged out successfully",
+		})
+	}
+}
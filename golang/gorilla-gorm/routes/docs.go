@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"common/openapi"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// SetupDocsRoutes serves the OpenAPI document of every route registered so
+// far and the Swagger UI. Call it after all other routes are set up.
+func SetupDocsRoutes(router *mux.Router) error {
+	var document []byte
+
+	// DOCS - OpenAPI 문서
+	router.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(document)
+	}).Methods("GET")
+
+	// DOCS - Swagger UI 페이지 및 정적 파일
+	docs := openapi.DocsHandler()
+	router.Handle("/docs", docs).Methods("GET")
+	router.Handle("/docs/{file}", docs).Methods("GET")
+
+	var routes []openapi.Route
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			routes = append(routes, openapi.Route{Method: method, Path: path})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	document, err = openapi.Build(routes)
+	return err
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"common/health"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// SetupHealthRoutes sets up the liveness and readiness probes
+func SetupHealthRoutes(router *mux.Router, checker *health.Checker) {
+	// HEALTH - 프로세스 생존 확인 (liveness)
+	router.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, http.StatusOK, checker.Live())
+	}).Methods("GET")
+
+	// HEALTH - 트래픽 수신 가능 여부 확인 (readiness: DB, 마이그레이션, 연결 풀, 종료 중 여부)
+	router.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Ready(r.Context())
+		sendJSON(w, readyStatus(report), report)
+	}).Methods("GET")
+}
+
+// readyStatus is 200 for a ready instance and 503 otherwise
+func readyStatus(report health.Report) int {
+	if report.OK() {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"common/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// SetupMetricsRoutes serves the Prometheus metrics
+func SetupMetricsRoutes(router *mux.Router, m *metrics.Metrics) {
+	// METRICS - Prometheus 수집 엔드포인트
+	router.Handle("/metrics", m.Handler()).Methods("GET")
+}
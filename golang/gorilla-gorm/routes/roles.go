@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"common/auth"
+	"common/dto"
+	"common/rbac"
+	"common/service"
+	"gorilla-gorm/middleware"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// SetupRoleRoutes sets up role listing and assignment routes
+func SetupRoleRoutes(router *mux.Router, users *service.UserService, tokens *auth.TokenManager) {
+	authenticate := middleware.Authenticate(tokens)
+	authorize := middleware.Authorize
+
+	// READ - 역할 및 권한 목록 조회
+	router.Handle("/api/roles", authenticate(authorize(rbac.RolesRead)(http.HandlerFunc(getRoles)))).Methods("GET")
+
+	// READ - 사용자 역할 조회
+	router.Handle("/api/users/{id}/roles", authenticate(authorize(rbac.RolesRead)(getUserRoles(users)))).Methods("GET")
+
+	// UPDATE - 사용자 역할 지정 (관리자 전용)
+	router.Handle("/api/users/{id}/roles", authenticate(authorize(rbac.RolesAssign)(setUserRoles(users)))).Methods("PUT")
+}
+
+// getRoles lists the roles of the access policy with their permissions
+func getRoles(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    dto.NewRoleResponses(),
+	})
+}
+
+// getUserRoles lists the roles held by a user
+func getUserRoles(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := users.Get(r.Context(), mux.Vars(r)["id"])
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		sendJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    dto.NewUserRolesResponse(user),
+		})
+	}
+}
+
+// setUserRoles replaces the roles held by a user
+func setUserRoles(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.UserRolesRequest
+		if err := bindJSON(r, &req); err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		user, err := users.SetRoles(r.Context(), mux.Vars(r)["id"], req)
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		sendJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    dto.NewUserRolesResponse(user),
+		})
+	}
+}
@@ -1,31 +1,55 @@
 package routes
 
 import (
+	"common/auth"
+	"common/dto"
+	"common/etag"
+	"common/models"
+	"common/patch"
+	"common/query"
+	"common/rbac"
+	"common/repository"
+	"common/service"
+	"common/validation"
 	"encoding/json"
-	"gorilla-gorm/config"
-	"gorilla-gorm/models"
+	"errors"
+	"gorilla-gorm/middleware"
 	"net/http"
-	"strconv"
 
 	"github.com/gorilla/mux"
 )
 
 // SetupUserRoutes sets up all user-related routes
-func SetupUserRoutes(router *mux.Router) {
-	// CREATE - 사용자 생성
-	router.HandleFunc("/api/users", createUser).Methods("POST")
+func SetupUserRoutes(router *mux.Router, users *service.UserService, tokens *auth.TokenManager) {
+	authenticate := middleware.Authenticate(tokens)
+	authorize := middleware.Authorize
+
+	// CREATE - 사용자 생성 (회원가입, 인증 불필요)
+	router.HandleFunc("/api/users", createUser(users)).Methods("POST")
 
 	// READ - 모든 사용자 조회
-	router.HandleFunc("/api/users", getUsers).Methods("GET")
+	router.Handle("/api/users", authenticate(authorize(rbac.UsersList)(getUsers(users)))).Methods("GET")
+
+	// READ - 삭제된 사용자(휴지통) 조회 (관리자 전용)
+	router.Handle("/api/users/trash", authenticate(authorize(rbac.UsersTrash)(getDeletedUsers(users)))).Methods("GET")
+
+	// UPDATE - 삭제된 사용자 복구 (관리자 전용)
+	router.Handle("/api/users/{id}/restore", authenticate(authorize(rbac.UsersTrash)(restoreUser(users)))).Methods("POST")
 
 	// READ - 특정 사용자 조회
-	router.HandleFunc("/api/users/{id}", getUser).Methods("GET")
+	router.Handle("/api/users/{id}", authenticate(authorize(rbac.UsersRead)(getUser(users)))).Methods("GET")
+
+	// UPDATE - 사용자 수정 (전체 교체)
+	router.Handle("/api/users/{id}", authenticate(authorize(rbac.UsersUpdate)(updateUser(users)))).Methods("PUT")
 
-	// UPDATE - 사용자 수정
-	router.HandleFunc("/api/users/{id}", updateUser).Methods("PUT")
+	// UPDATE - 사용자 부분 수정 (JSON Merge Patch / JSON Patch)
+	router.Handle("/api/users/{id}", authenticate(authorize(rbac.UsersUpdate)(patchUser(users)))).Methods("PATCH")
 
 	// DELETE - 사용자 삭제
-	router.HandleFunc("/api/users/{id}", deleteUser).Methods("DELETE")
+	router.Handle("/api/users/{id}", authenticate(authorize(rbac.UsersDelete)(deleteUser(users)))).Methods("DELETE")
+
+	// DELETE - 사용자의 모든 세션(리프레시 토큰) 폐기 (관리자 전용)
+	router.Handle("/api/users/{id}/sessions", authenticate(authorize(rbac.SessionsRevoke)(revokeSessions(users)))).Methods("DELETE")
 }
 
 // sendJSON sends a JSON response
@@ -35,160 +59,191 @@ func sendJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// createUser creates a new user
-func createUser(w http.ResponseWriter, r *http.Request) {
-	var user models.User
-
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		sendJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
-		return
-	}
+// sendError responds with the problem a service error maps to
+func sendError(w http.ResponseWriter, r *http.Request, err error) {
+	middleware.SendProblem(w, r, service.Problem(err))
+}
 
-	if err := config.DB.Create(&user).Error; err != nil {
-		sendJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
-		return
+// bindJSON reads the request body and strictly decodes it into v
+func bindJSON(r *http.Request, v interface{}) error {
+	body, err := validation.ReadBody(r.Body)
+	if err != nil {
+		return err
 	}
+	return validation.Decode(body, v)
+}
 
-	sendJSON(w, http.StatusCreated, map[string]interface{}{
+// sendUser responds with a user and its ETag
+func sendUser(w http.ResponseWriter, status int, user *models.User) {
+	w.Header().Set("ETag", etag.For(user.ID, user.Version))
+	sendJSON(w, status, map[string]interface{}{
 		"success": true,
-		"data":    user,
+		"data":    dto.NewUserResponse(user),
 	})
 }
 
-// getUsers retrieves all users
-func getUsers(w http.ResponseWriter, r *http.Request) {
-	var users []models.User
-
-	if err := config.DB.Order("id ASC").Find(&users).Error; err != nil {
-		sendJSON(w, http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
-		return
+// createUser creates a new user
+func createUser(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.CreateUserRequest
+
+		if err := bindJSON(r, &req); err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		user, err := users.Create(r.Context(), req)
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		sendUser(w, http.StatusCreated, user)
 	}
+}
 
-	sendJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    users,
-	})
+// getUsers retrieves all users
+func getUsers(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listUsers(w, r, users, repository.Active, query.Users)
+	}
 }
 
-// getUser retrieves a specific user by ID
-func getUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.ParseUint(vars["id"], 10, 32)
-	if err != nil {
-		sendJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Invalid user ID",
-		})
-		return
+// getDeletedUsers lists soft-deleted users, most recently deleted first
+func getDeletedUsers(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listUsers(w, r, users, repository.Trashed, query.DeletedUsers)
 	}
+}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		sendJSON(w, http.StatusNotFound, map[string]interface{}{
-			"success": false,
-			"error":   "User not found",
-		})
+// listUsers responds with one page of the users of a scope
+func listUsers(w http.ResponseWriter, r *http.Request, users *service.UserService, scope repository.Scope, spec *query.Spec) {
+	page, err := users.List(r.Context(), scope, spec, r.URL.Path, r.URL.RawQuery)
+	if err != nil {
+		sendError(w, r, err)
 		return
 	}
 
+	w.Header().Set("Link", page.Link)
 	sendJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"data":    user,
+		"data":    dto.NewUserResponses(page.Users),
+		"meta":    page.Meta,
 	})
 }
 
-// updateUser updates an existing user
-func updateUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.ParseUint(vars["id"], 10, 32)
-	if err != nil {
-		sendJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Invalid user ID",
-		})
-		return
+// getUser retrieves a specific user by ID
+func getUser(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := users.Get(r.Context(), mux.Vars(r)["id"])
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		tag := etag.For(user.ID, user.Version)
+		if etag.NotModified(r.Header.Get("If-None-Match"), tag) {
+			w.Header().Set("ETag", tag)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		sendUser(w, http.StatusOK, user)
 	}
+}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		sendJSON(w, http.StatusNotFound, map[string]interface{}{
-			"success": false,
-			"error":   "User not found",
-		})
-		return
+// updateUser replaces a user's email, username and password
+func updateUser(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var updateData dto.UpdateUserRequest
+		if err := bindJSON(r, &updateData); err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		user, err := users.Replace(r.Context(), mux.Vars(r)["id"], r.Header.Get("If-Match"), updateData)
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		sendUser(w, http.StatusOK, user)
 	}
+}
 
-	var updateData models.User
-	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
-		sendJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
-		return
+// patchUser updates only the fields supplied by a merge patch or JSON patch
+func patchUser(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := validation.ReadBody(r.Body)
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		user, err := users.Patch(r.Context(), mux.Vars(r)["id"], r.Header.Get("If-Match"), r.Header.Get("Content-Type"), body)
+		if err != nil {
+			if errors.Is(err, patch.ErrUnsupportedMediaType) {
+				w.Header().Set("Accept-Patch", patch.AcceptPatch)
+			}
+			sendError(w, r, err)
+			return
+		}
+
+		sendUser(w, http.StatusOK, user)
 	}
+}
 
-	// Update user fields
-	if err := config.DB.Model(&user).Updates(map[string]interface{}{
-		"email":    updateData.Email,
-		"username": updateData.Username,
-		"password": updateData.Password,
-	}).Error; err != nil {
-		sendJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
+// deleteUser moves a user to the trash, or removes it permanently with ?hard=true
+func deleteUser(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hard, err := service.ParseHard(r.URL.Query().Get("hard"))
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		if err := users.Delete(r.Context(), mux.Vars(r)["id"], r.Header.Get("If-Match"), hard); err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		message := "User deleted successfully"
+		if hard {
+			message = "User permanently deleted"
+		}
+		sendJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": message,
 		})
-		return
 	}
-
-	// Fetch updated user
-	config.DB.First(&user, id)
-
-	sendJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    user,
-	})
 }
 
-// deleteUser deletes a user
-func deleteUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.ParseUint(vars["id"], 10, 32)
-	if err != nil {
-		sendJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Invalid user ID",
-		})
-		return
-	}
+// restoreUser moves a soft-deleted user out of the trash
+func restoreUser(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := users.Restore(r.Context(), mux.Vars(r)["id"])
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
 
-	var user models.User
-	if err := config.DB.First(&user, id).Error; err != nil {
-		sendJSON(w, http.StatusNotFound, map[string]interface{}{
-			"success": false,
-			"error":   "User not found",
-		})
-		return
+		sendUser(w, http.StatusOK, user)
 	}
+}
 
-	if err := config.DB.Delete(&user).Error; err != nil {
-		sendJSON(w, http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
+// revokeSessions revokes every refresh token of a user (admin only)
+func revokeSessions(users *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		revoked, err := users.RevokeSessions(r.Context(), mux.Vars(r)["id"])
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
+
+		sendJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Sessions revoked successfully",
+			"data":    map[string]interface{}{"revoked": revoked},
 		})
-		return
 	}
-
-	sendJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"message": "User deleted successfully",
-	})
 }